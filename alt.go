@@ -0,0 +1,501 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Or runs all of its alternatives in lockstep and finishes as soon as
+// one of them reaches a final state. An alternative is dropped when it
+// fails on a token; Or fails when all alternatives have been dropped.
+// Since every alternative sees every token, no token needs to be
+// replayed.
+func Or(its ...Iteratee) Iteratee {
+	return orI(its)
+}
+
+// orI implements Or(). Each element is the current state of a live
+// alternative.
+type orI []Iteratee
+
+func (it orI) Final() error {
+	errs := make([]error, 0, len(it))
+	for _, i := range it {
+		err := i.Final()
+		if err == nil || isCut(err) {
+			return err
+		}
+		errs = append(errs, err)
+	}
+	return altError(errs)
+}
+
+func (it orI) Next(token []byte) (Iteratee, bool, error) {
+	alive := make(orI, 0, len(it))
+	var errs []error
+	for _, i := range it {
+		next, read, err := step(i, token)
+		if isCut(err) {
+			return nil, false, err
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if next == nil {
+			return nil, read, nil
+		}
+		alive = append(alive, next)
+	}
+	switch len(alive) {
+	case 0:
+		return nil, false, altError(errs)
+	case 1:
+		return alive[0], true, nil
+	}
+	return alive, true, nil
+}
+
+// Alt commits to the first of its alternatives that accepts the next
+// token. Unlike Or, a later failure of the chosen alternative is not
+// retried with the other ones, which makes Alt cheaper and its errors
+// more specific when alternatives can be told apart by their first
+// token.
+func Alt(its ...Iteratee) Iteratee {
+	return altI(its)
+}
+
+// altI implements Alt().
+type altI []Iteratee
+
+func (it altI) Final() error { return orI(it).Final() }
+func (it altI) Next(token []byte) (Iteratee, bool, error) {
+	errs := make([]error, 0, len(it))
+	for _, i := range it {
+		next, read, err := step(i, token)
+		if err == nil || isCut(err) {
+			return next, read, err
+		}
+		errs = append(errs, err)
+	}
+	return nil, false, altError(errs)
+}
+
+// First feeds every token to all of its sub-Iteratees and finishes as
+// soon as one of them reaches a final state, discarding the others.
+// Unlike Or, the first error from any sub-Iteratee aborts First. At the
+// end of input, it succeeds if any of them does, or fails with the error
+// of the first one.
+func First(its ...Iteratee) Iteratee {
+	return firstI(its)
+}
+
+// firstI implements First().
+type firstI []Iteratee
+
+func (it firstI) Final() error {
+	// Like Next, succeed with the first sub-Iteratee that finishes.
+	var first error
+	for _, i := range it {
+		err := i.Final()
+		if err == nil {
+			return nil
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (it firstI) Next(token []byte) (Iteratee, bool, error) {
+	next := make(firstI, len(it))
+	for k, i := range it {
+		n, read, err := step(i, token)
+		if err != nil {
+			return nil, false, err
+		}
+		if n == nil {
+			return nil, read, nil
+		}
+		next[k] = n
+	}
+	return next, true, nil
+}
+
+// LongestMatch runs all of its alternatives in lockstep like Or, but
+// keeps going after an alternative finishes as long as others are still
+// alive, so that it finishes with the alternative that consumes the most
+// tokens. Since consumed tokens cannot be given back, it is an
+// ErrBacktrack if all the longer alternatives fail after consuming
+// tokens beyond the longest finished one.
+func LongestMatch(its ...Iteratee) Iteratee {
+	return longestI{its, false, 0}
+}
+
+// longestI implements LongestMatch(). Matched is true when an
+// alternative has finished; Pending is the number of tokens consumed
+// since then.
+type longestI struct {
+	Alive   []Iteratee
+	Matched bool
+	Pending int
+}
+
+func (it longestI) Final() error {
+	err := orI(it.Alive).Final()
+	if err == nil || !it.Matched || isCut(err) {
+		return err
+	}
+	if it.Pending > 0 {
+		return ErrBacktrack(it.Pending)
+	}
+	return nil
+}
+
+func (it longestI) Next(token []byte) (Iteratee, bool, error) {
+	var (
+		alive              []Iteratee
+		errs               []error
+		finished, consumed bool
+	)
+	for _, i := range it.Alive {
+		next, read, err := step(i, token)
+		switch {
+		case isCut(err):
+			return nil, false, err
+		case err != nil:
+			errs = append(errs, err)
+		case next == nil:
+			finished = true
+			consumed = consumed || read
+		default:
+			alive = append(alive, next)
+		}
+	}
+	if len(alive) == 0 {
+		switch {
+		case finished:
+			return nil, consumed, nil
+		case !it.Matched:
+			return nil, false, altError(errs)
+		case it.Pending > 0:
+			return nil, false, ErrBacktrack(it.Pending)
+		}
+		return nil, false, nil
+	}
+	switch {
+	case finished && consumed:
+		it.Matched, it.Pending = true, 0
+	case finished:
+		it.Matched, it.Pending = true, 1
+	case it.Matched:
+		it.Pending++
+	}
+	it.Alive = alive
+	return it, true, nil
+}
+
+// Permutation matches each of its members exactly once, in any order.
+// Like Alt, the member to run next is the first remaining one that
+// accepts the next token. It fails with ErrDuplicate when only a member
+// that has already been matched accepts the token.
+func Permutation(its ...Iteratee) Iteratee {
+	return permI{its, make([]bool, len(its)), len(its), nil}
+}
+
+// permI implements Permutation(). Done marks the members matched or
+// running; Left counts the others. Cur is the running member.
+type permI struct {
+	Its  []Iteratee
+	Done []bool
+	Left int
+	Cur  Iteratee
+}
+
+func (it permI) Final() error {
+	if it.Cur != nil {
+		if err := it.Cur.Final(); err != nil {
+			return err
+		}
+	}
+	for i, done := range it.Done {
+		if !done {
+			if err := it.Its[i].Final(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (it permI) Next(token []byte) (Iteratee, bool, error) {
+	if it.Cur != nil {
+		next, read, err := it.Cur.Next(token)
+		if err != nil {
+			return nil, false, err
+		}
+		it.Cur = next
+		if next == nil && it.Left == 0 {
+			return nil, read, nil
+		}
+		return it, read, nil
+	}
+	if it.Left == 0 {
+		return nil, false, nil
+	}
+	var errs []error
+	for i, done := range it.Done {
+		if done {
+			continue
+		}
+		next, read, err := step(it.Its[i], token)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		it.Done = append([]bool(nil), it.Done...)
+		it.Done[i] = true
+		it.Left--
+		it.Cur = next
+		if next == nil && it.Left == 0 {
+			return nil, read, nil
+		}
+		return it, read, nil
+	}
+	for i, done := range it.Done {
+		if done {
+			// Only a member that would consume the token again is a
+			// duplicate; one that accepts without consuming, e.g. a Star,
+			// says nothing about the token.
+			if _, read, err := step(it.Its[i], token); err == nil && read {
+				return nil, false, ErrDuplicate
+			}
+		}
+	}
+	return nil, false, altError(errs)
+}
+
+// ErrDuplicate reports a repeated member of a Permutation.
+var ErrDuplicate = errors.New("duplicate")
+
+// Both feeds every token to both a and b, and succeeds when both of
+// them do. A token is consumed when either of them consumes it; once
+// one of them finishes, the other one gets the remaining tokens alone.
+func Both(a, b Iteratee) Iteratee {
+	return bothI{a, b}
+}
+
+// bothI implements Both(). A finished side is set to nil.
+type bothI struct {
+	A, B Iteratee
+}
+
+func (it bothI) Final() error {
+	for _, i := range []Iteratee{it.A, it.B} {
+		if i != nil {
+			if err := i.Final(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (it bothI) Next(token []byte) (Iteratee, bool, error) {
+	var readA, readB bool
+	if it.A != nil {
+		var err error
+		if it.A, readA, err = step(it.A, token); err != nil {
+			return nil, false, err
+		}
+	}
+	if it.B != nil {
+		var err error
+		if it.B, readB, err = step(it.B, token); err != nil {
+			return nil, false, err
+		}
+	}
+	if it.A == nil && it.B == nil {
+		return nil, readA || readB, nil
+	}
+	return it, true, nil
+}
+
+// Interleave routes each token to subs[classify(token)], e.g. to parse
+// the lines of two interleaved logs separately. It succeeds when all of
+// subs do at the end of input. It fails with ErrUnexpected when the
+// class is out of range, or when the chosen sub-Iteratee has finished
+// and cannot take the token.
+func Interleave(classify func(token []byte) int, subs ...Iteratee) Iteratee {
+	return interleaveI{classify, subs}
+}
+
+// interleaveI implements Interleave(). A finished sub-Iteratee is set to
+// nil.
+type interleaveI struct {
+	Classify func([]byte) int
+	Subs     []Iteratee
+}
+
+func (it interleaveI) Final() error {
+	for _, i := range it.Subs {
+		if i != nil {
+			if err := i.Final(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (it interleaveI) Next(token []byte) (Iteratee, bool, error) {
+	c := it.Classify(token)
+	if c < 0 || c >= len(it.Subs) || it.Subs[c] == nil {
+		return nil, false, ErrUnexpected
+	}
+	next, read, err := step(it.Subs[c], token)
+	if err != nil {
+		return nil, false, err
+	}
+	if !read {
+		return nil, false, ErrUnexpected
+	}
+	it.Subs = append([]Iteratee(nil), it.Subs...)
+	it.Subs[c] = next
+	return it, true, nil
+}
+
+// Switch dispatches on the exact value of the next token: it consumes
+// the token and continues with cases[token]. Otherwise it continues
+// with def on the same token, or fails listing the keys of cases when def
+// is nil. Unlike Alt, the cost of the lookup does not grow with the
+// number of cases.
+func Switch(cases map[string]Iteratee, def Iteratee) Iteratee {
+	return switchI{cases, def, true}
+}
+
+// SwitchPeek is like Switch but cases[token] also sees the token.
+func SwitchPeek(cases map[string]Iteratee, def Iteratee) Iteratee {
+	return switchI{cases, def, false}
+}
+
+// switchI implements Switch() and SwitchPeek(). Consume tells whether
+// the matched token is consumed.
+type switchI struct {
+	Cases   map[string]Iteratee
+	Def     Iteratee
+	Consume bool
+}
+
+func (it switchI) Final() error {
+	if it.Def != nil {
+		return it.Def.Final()
+	}
+	return it.err()
+}
+
+func (it switchI) Next(token []byte) (Iteratee, bool, error) {
+	if next, ok := it.Cases[string(token)]; ok {
+		return next, it.Consume, nil
+	}
+	if it.Def != nil {
+		return it.Def, false, nil
+	}
+	return nil, false, it.err()
+}
+
+func (it switchI) err() error {
+	keys := make([]string, 0, len(it.Cases))
+	for k := range it.Cases {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return MatchOneOf(keys...).Final()
+}
+
+// Cut runs it and commits to it once it has consumed a token: any
+// later error from it becomes a CutErr, which alternatives (Or, Alt and
+// LongestMatch) and repetitions (Star, RepeatRange and the like) pass
+// on instead of trying something else. This keeps errors close to the
+// actual mistake.
+func Cut(it Iteratee) Iteratee {
+	return cutI{it, false}
+}
+
+// cutI implements Cut(). Committed becomes true once A has consumed a
+// token.
+type cutI struct {
+	A         Iteratee
+	Committed bool
+}
+
+func (it cutI) Final() error { return it.wrap(it.A.Final()) }
+func (it cutI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		return nil, false, it.wrap(err)
+	}
+	if next == nil {
+		return nil, read, nil
+	}
+	return cutI{next, it.Committed || read}, read, nil
+}
+
+func (it cutI) wrap(err error) error {
+	if err == nil || !it.Committed || isCut(err) {
+		return err
+	}
+	return CutErr{err}
+}
+
+// CutErr is an error after a commit point (see Cut).
+type CutErr struct {
+	Err error
+}
+
+func (e CutErr) Error() string { return e.Err.Error() }
+func (e CutErr) Unwrap() error { return e.Err }
+
+func isCut(err error) bool {
+	var c CutErr
+	return errors.As(err, &c)
+}
+
+// AltErr reports that every alternative failed. It holds the error of
+// each alternative.
+type AltErr []error
+
+func (e AltErr) Error() string {
+	if len(e) == 0 {
+		return "no alternative"
+	}
+	msgs := make([]string, 0, len(e))
+	seen := map[string]bool{}
+	for _, err := range e {
+		msg := err.Error()
+		if !seen[msg] {
+			seen[msg] = true
+			msgs = append(msgs, msg)
+		}
+	}
+	return "no alternative matched: " + strings.Join(msgs, "; ")
+}
+
+// altError returns the only error in errs or all of them as an AltErr.
+func altError(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return AltErr(errs)
+}
+
+// ErrBacktrack reports the number of tokens that have to be given back
+// to the input, which is not possible.
+type ErrBacktrack int
+
+func (e ErrBacktrack) Error() string {
+	return fmt.Sprintf("cannot give back %s consumed beyond the match", plural(int(e), "token"))
+}
@@ -0,0 +1,301 @@
+package stream
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOr(t *testing.T) {
+	ab := Seq(Star(Or(Match("a"), Match("b"))), EOF)
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		OK    bool
+	}{
+		{"a b b a", ab, true},
+		{"", ab, true},
+		{"a c", ab, false},
+		// Alternatives sharing a prefix are resolved without backtracking.
+		{"x y", Seq(Or(Seq(Match("x"), Match("z")), Seq(Match("x"), Match("y"))), EOF), true},
+		{"x w", Seq(Or(Seq(Match("x"), Match("z")), Seq(Match("x"), Match("y"))), EOF), false},
+		// A branch that transitions without consuming the token.
+		{"a", Seq(Or(Seq(SkipAny("b"), Match("a")), Match("c")), EOF), true},
+		// Final with several live branches.
+		{"x", Or(Seq(Match("x"), Match("y")), Seq(Match("x"), SkipAny("z"))), true},
+		{"x", Or(Seq(Match("x"), Match("y")), Seq(Match("x"), Match("z"))), false},
+		{"a", Or(), false},
+	} {
+		err := runWords(i.Input, i.It)
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+}
+
+func TestOrError(t *testing.T) {
+	err := runWords("c", Or(Match("a"), Match("b"), Match("a")))
+	te, ok := err.(TokenErr)
+	if !ok {
+		t.Fatalf("expect TokenErr; got %#v", err)
+	}
+	alt, ok := te.Err.(AltErr)
+	if !ok || len(alt) != 3 {
+		t.Fatalf("expect AltErr of 3 errors; got %#v", te.Err)
+	}
+	if expected := `no alternative matched: expect "a"; expect "b"`; alt.Error() != expected {
+		t.Errorf("expect %q; got %q", expected, alt.Error())
+	}
+}
+
+func TestAlt(t *testing.T) {
+	kv := Seq(Star(Alt(Seq(Match("set"), Skip, Skip), Seq(Match("del"), Skip), Match("nop"))), EOF)
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		OK    bool
+	}{
+		{"set a 1 del a nop", kv, true},
+		{"", kv, true},
+		{"del", kv, false},
+		{"get a", kv, false},
+		// The first accepting alternative is chosen...
+		{"x y", Seq(Alt(Seq(Match("x"), Match("y")), Seq(Match("x"), Match("z"))), EOF), true},
+		// ...and never given up.
+		{"x z", Seq(Alt(Seq(Match("x"), Match("y")), Seq(Match("x"), Match("z"))), EOF), false},
+		{"", Alt(Match("x"), SkipAny("y")), true},
+		{"", Alt(Match("x"), Match("y")), false},
+	} {
+		err := runWords(i.Input, i.It)
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+}
+
+func TestBoth(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Tokens []string
+		OK     bool
+	}{
+		{"( a ( b ) )", []string{"(", "a", "(", "b", ")", ")"}, true},
+		{"( a ( b )", []string{"(", "a", "(", "b", ")"}, false},
+		{") a", nil, false},
+	} {
+		var tokens CopyIteratee
+		balance := Balance(0)
+		err := runWords(i.Input, Both(Filter(func(token []byte) bool {
+			return string(token) == "(" || string(token) == ")"
+		}, balance), &tokens))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+		if !reflect.DeepEqual([]string(tokens), i.Tokens) {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, tokens)
+		}
+	}
+
+	// Both finishes when both sides do.
+	if err := runWords("a b c", Seq(Both(Match("a"), Seq(Match("a"), Match("b"))), Match("c"), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestFirst(t *testing.T) {
+	until := func(s string) Iteratee { return Seq(Star(Seq(Not(Match(s)), Skip)), Match(s)) }
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		OK    bool
+	}{
+		{"a b ; c", Seq(First(until(";"), until(".")), Match("c"), EOF), true},
+		{"a b . c", Seq(First(until(";"), until(".")), Match("c"), EOF), true},
+		{"a b c", First(until(";"), until(".")), false},
+		// Errors are not tolerated.
+		{"a b ;", First(until(";"), Match("x")), false},
+		{"a b ;", Or(until(";"), Match("x")), true},
+		// At the end of input, any final sub-Iteratee will do.
+		{"", First(Match("x"), Star(Skip)), true},
+		{"", First(Match("x"), Match("y")), false},
+	} {
+		err := runWords(i.Input, i.It)
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+}
+
+func TestLongestMatch(t *testing.T) {
+	op := func(s ...string) Iteratee {
+		its := make([]Iteratee, len(s))
+		for i, c := range s {
+			its[i] = Match(c)
+		}
+		return Seq(its...)
+	}
+	// Operators over single character tokens.
+	ops := Star(LongestMatch(op("<"), op("<", "="), op("<", "<", "="), op("=")))
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		OK    bool
+	}{
+		{"< = < < = < =", Seq(ops, EOF), true},
+		{"< < = =", Seq(ops, EOF), true},
+		{"< x", Seq(ops, Match("x"), EOF), true},
+		// The longest alternative is chosen even if a shorter one is listed first.
+		{"< = x", Seq(LongestMatch(op("<"), op("<", "=")), Match("x"), EOF), true},
+		{"< = x", Seq(LongestMatch(op("<", "="), op("<")), Match("x"), EOF), true},
+		{"<", Seq(LongestMatch(op("<", "="), op("<")), EOF), true},
+		// "<" matches but "=" is gone with the failed "< = =".
+		{"< = x", LongestMatch(op("<"), op("<", "=", "=")), false},
+		{"< =", LongestMatch(op("<"), op("<", "=", "=")), false},
+		{"x", LongestMatch(op("<"), op("=")), false},
+	} {
+		err := runWords(i.Input, i.It)
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+}
+
+func TestCut(t *testing.T) {
+	stmt := func(cut func(Iteratee) Iteratee) Iteratee {
+		return Or(
+			Seq(Match("let"), cut(Seq(Skip, Match("="), Skip))),
+			Seq(Skip, Skip, Skip, Match(";")))
+	}
+	noCut := func(it Iteratee) Iteratee { return it }
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		Err   string
+	}{
+		{"let x = 1 f a b ;", Seq(Star(stmt(Cut)), EOF), ""},
+		{"let x : 1", Seq(Star(stmt(noCut)), EOF), `token "1" at offset 8 (token #3): expect ";"`},
+		{"let x : 1", Seq(Star(stmt(Cut)), EOF), `token ":" at offset 6 (token #2): expect "="`},
+		{"let x", Seq(Star(stmt(Cut)), EOF), `expect "="`},
+		{"let x : 1", Seq(Alt(Seq(Match("let"), Cut(Seq(Skip, Match("=")))), Skip), EOF), `token ":" at offset 6 (token #2): expect "="`},
+		{"let x : 1", Seq(Plus(stmt(Cut)), EOF), `token ":" at offset 6 (token #2): expect "="`},
+		// Not committed before the first token.
+		{"let y", Seq(Or(Seq(Match("let"), Cut(Seq(Match("x"), Match("=")))), Seq(Match("let"), Match("y"))), EOF), ""},
+	} {
+		err := runWords(i.Input, i.It)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
+
+func TestPermutation(t *testing.T) {
+	header := func(name string) Iteratee { return Seq(Match(name), Match(":"), Skip) }
+	headers := Seq(Permutation(header("host"), header("port"), header("user")), EOF)
+	for _, i := range []struct {
+		Input, Err string
+	}{
+		{"host : a port : 1 user : b", ""},
+		{"user : b host : a port : 1", ""},
+		{"port : 1 user : b host : a", ""},
+		{"port : 1 port : 2 host : a", `token "port" at offset 9 (token #3): duplicate`},
+		{"port : 1 host : a", `expect "user"`},
+		{"port : 1 host : a x", `token "x" at offset 18 (token #6): expect "user"`},
+		{"port : 1 host : a x : 3", `token "x" at offset 18 (token #6): expect "user"`},
+		{"port 1", `token "1" at offset 5 (token #1): expect ":"`},
+	} {
+		err := runWords(i.Input, headers)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+
+	// A member that accepts without consuming is not a duplicate.
+	tagged := Seq(Permutation(Star(Match("t")), header("host"), header("port")), EOF)
+	for _, i := range []struct {
+		Input, Err string
+	}{
+		{"t t host : a port : 1", ""},
+		{"x", `token "x" at offset 0 (token #0): no alternative matched: expect "host"; expect "port"`},
+		{"t host : a t", `token "t" at offset 11 (token #4): duplicate`},
+	} {
+		err := runWords(i.Input, tagged)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	stmt := Switch(map[string]Iteratee{
+		"print": Seq(Skip, Match(";")),
+		"if":    Seq(Skip, Match("then"), Skip, Match(";")),
+		"skip":  Match(";"),
+	}, nil)
+	peek := SwitchPeek(map[string]Iteratee{
+		"(": Balanced("(", ")", 0),
+	}, Skip)
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		Err   string
+	}{
+		{"print x ; if c then y ; skip ;", Star(stmt), ""},
+		{"", stmt, `expect one of "if", "print", "skip"`},
+		{"while x ;", stmt, `token "while" at offset 0 (token #0): expect one of "if", "print", "skip"`},
+		{"print x :", stmt, `token ":" at offset 8 (token #2): expect ";"`},
+		{"( a ( b ) ) c", Star(peek), ""},
+		{"( a", Star(peek), `unclosed "(" opened 2 tokens before: expect ")"`},
+	} {
+		err := runWords(i.Input, Seq(i.It, EOF))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	// Lines from stdout start with "o", those from stderr with "e".
+	stream := func(token []byte) int {
+		if len(token) > 0 && token[0] == 'e' {
+			return 1
+		}
+		return 0
+	}
+	out := Seq(Match("o:start"), Star(Match("o:line")), Match("o:done"))
+	errs := Star(Match("e:warn"))
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"o:start e:warn o:line e:warn o:done", true},
+		{"o:start o:done", true},
+		{"e:warn o:start o:done e:warn", true},
+		{"o:start e:warn o:line", false},
+		{"o:start e:fatal o:done", false},
+		{"o:start o:done o:line", false},
+	} {
+		err := runWords(i.Input, Interleave(stream, out, errs))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+}
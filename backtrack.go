@@ -0,0 +1,158 @@
+package stream
+
+// Backtracker is a PushbackEnumerator whose methods create Iteratees
+// that rewind the input. Such Iteratees must be run with the Backtracker
+// itself (e.g. Run(b, it)), and must not be run inside Or, First, Both
+// or other combinators that feed the same token to several Iteratees,
+// since a rewind affects all of them.
+type Backtracker struct {
+	PushbackEnumerator
+}
+
+// NewBacktracker creates a Backtracker reading from e. Since its
+// Iteratees keep the tokens they may have to put back, it keeps no
+// history for UnreadToken.
+func NewBacktracker(e Enumerator) *Backtracker {
+	return &Backtracker{PushbackEnumerator{e: e}}
+}
+
+// Try runs its alternatives one at a time: when an alternative fails,
+// even after consuming some tokens, the input is rewound and the next
+// alternative is run on it. Try finishes with the first alternative that
+// finishes, and fails when all of them fail. A CutErr is not retried.
+func (b *Backtracker) Try(its ...Iteratee) Iteratee {
+	if len(its) == 0 {
+		return Fail(AltErr(nil))
+	}
+	return tryI{b, its[1:], its[0], -1, nil, nil}
+}
+
+// tryI implements Backtracker.Try(). Start is the position where Cur
+// started (-1 before the first token). Tokens holds the tokens from
+// Start onwards that have been consumed so far, which may be more than
+// those consumed by Cur when a nested Iteratee has rewound.
+type tryI struct {
+	B      *Backtracker
+	Its    []Iteratee
+	Cur    Iteratee
+	Start  int
+	Tokens *tokenList
+	Errs   []error
+}
+
+func (it tryI) Final() error {
+	err := it.Cur.Final()
+	tokens := it.Tokens.slice()
+	for _, i := range it.Its {
+		if err == nil || isCut(err) {
+			return err
+		}
+		it.Errs = append(it.Errs[:len(it.Errs):len(it.Errs)], err)
+		err = replayFinal(i, tokens)
+	}
+	if err == nil || isCut(err) {
+		return err
+	}
+	return altError(append(it.Errs, err))
+}
+
+func (it tryI) Next(token []byte) (Iteratee, bool, error) {
+	if it.Start < 0 {
+		it.Start = it.B.pos
+	}
+	pos := it.B.pos
+	next, read, err := it.Cur.Next(token)
+	if err != nil {
+		errs := append(it.Errs[:len(it.Errs):len(it.Errs)], err)
+		if len(it.Its) == 0 || isCut(err) {
+			return nil, false, altError(errs)
+		}
+		it.B.unread(it.Tokens.slice()[:pos-it.Start])
+		return tryI{it.B, it.Its[1:], it.Its[0], it.Start, it.Tokens, errs}, false, nil
+	}
+	if read && pos-it.Start == it.Tokens.len() {
+		it.Tokens = it.Tokens.push(token)
+	}
+	if next == nil {
+		return nil, read, nil
+	}
+	it.Cur = next
+	return it, read, nil
+}
+
+// replayFinal runs it on tokens and then the end of input. It fails with
+// ErrBacktrack when it finishes before consuming all tokens.
+func replayFinal(it Iteratee, tokens [][]byte) error {
+	for i, t := range tokens {
+		next, read, err := step(it, t)
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			if n := len(tokens) - i - 1; n > 0 || !read {
+				if !read {
+					n++
+				}
+				return ErrBacktrack(n)
+			}
+			return nil
+		}
+		it = next
+	}
+	return it.Final()
+}
+
+// Lookahead checks that the next tokens match its in sequence without
+// consuming them: once the last of its finishes, the input is rewound
+// and Lookahead finishes. It fails when they do not match. Since the
+// input cannot be rewound at the end of input, it is an ErrBacktrack if
+// they match there after consuming some tokens.
+func (b *Backtracker) Lookahead(its ...Iteratee) Iteratee {
+	if len(its) == 0 {
+		return Succeed()
+	}
+	return lookI{b, its[1:], its[0], -1, nil}
+}
+
+// lookI implements Backtracker.Lookahead(). Cur is the running member of
+// its and Its the ones after it. Start and Tokens are as in tryI.
+type lookI struct {
+	B      *Backtracker
+	Its    []Iteratee
+	Cur    Iteratee
+	Start  int
+	Tokens *tokenList
+}
+
+func (it lookI) Final() error {
+	if err := Seq(append([]Iteratee{it.Cur}, it.Its...)...).Final(); err != nil {
+		return err
+	}
+	if n := it.Tokens.len(); n > 0 {
+		return ErrBacktrack(n)
+	}
+	return nil
+}
+
+func (it lookI) Next(token []byte) (Iteratee, bool, error) {
+	if it.Start < 0 {
+		it.Start = it.B.pos
+	}
+	pos := it.B.pos
+	next, read, err := it.Cur.Next(token)
+	if err != nil {
+		return nil, false, err
+	}
+	if next == nil && len(it.Its) == 0 {
+		it.B.unread(it.Tokens.slice()[:pos-it.Start])
+		return nil, false, nil
+	}
+	if read && pos-it.Start == it.Tokens.len() {
+		it.Tokens = it.Tokens.push(token)
+	}
+	if next == nil {
+		next, it.Its = it.Its[0], it.Its[1:]
+	}
+	it.Cur = next
+	return it, read, nil
+}
@@ -0,0 +1,96 @@
+package stream
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func runBacktrack(s string, it func(b *Backtracker) Iteratee) error {
+	b := NewBacktracker(NewScanEnumeratorWith(strings.NewReader(s), bufio.ScanWords))
+	return Run(b, it(b))
+}
+
+func TestTry(t *testing.T) {
+	// Alternatives sharing a long prefix.
+	call := func(b *Backtracker) Iteratee {
+		return Seq(Star(b.Try(
+			Seq(Skip, Match("("), Match(")"), Match(";")),
+			Seq(Skip, Match("("), Match(")"), Match("{"), Match("}")),
+			Seq(Skip, Match(";")))), EOF)
+	}
+	// Nested rewinds.
+	nested := func(b *Backtracker) Iteratee {
+		return Seq(b.Try(
+			Seq(b.Try(Seq(Match("a"), Match("b"), Match("x")), Seq(Match("a"), Match("b"))), Match("c"), Match("y")),
+			Seq(Match("a"), Match("b"), Match("c"), Match("z"))), EOF)
+	}
+	// The chosen alternative consumes less than the failed one.
+	short := func(b *Backtracker) Iteratee {
+		return Seq(b.Try(Seq(Match("a"), Match("b"), Match("c")), Match("a")), Match("b"), Match("d"), EOF)
+	}
+	for _, i := range []struct {
+		Input string
+		It    func(b *Backtracker) Iteratee
+		Err   string
+	}{
+		{"f ( ) ; g ( ) { } h ;", call, ""},
+		{"f ( ) { } g ( ) ;", call, ""},
+		{"f ( ) [", call, `token "(": no alternative matched: expect ";"; expect "{"`},
+		{"a b c y", nested, ""},
+		{"a b c z", nested, ""},
+		{"a b x c y", nested, ""},
+		{"a b x c z", nested, `token "x": no alternative matched: expect "y"; expect "c"`},
+		{"a b d", short, ""},
+		{"a b", short, `no alternative matched: expect "c"; cannot give back 1 token consumed beyond the match`},
+		{"a b c", nested, `no alternative matched: expect "y"; expect "z"`},
+	} {
+		err := runBacktrack(i.Input, i.It)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
+
+func TestLookahead(t *testing.T) {
+	// A statement is either "label :" or "expr ;", told apart by the
+	// second token.
+	stmts := func(b *Backtracker) Iteratee {
+		label := Seq(b.Lookahead(Skip, Match(":")), Skip, Match(":"))
+		expr := Seq(Skip, Match(";"))
+		return Seq(Star(b.Try(label, expr)), EOF)
+	}
+	// Rewinding after a match that consumed the current token.
+	twice := func(b *Backtracker) Iteratee {
+		return Seq(b.Lookahead(Match("a"), Match("b")), Match("a"), Match("b"), EOF)
+	}
+	for _, i := range []struct {
+		Input string
+		It    func(b *Backtracker) Iteratee
+		Err   string
+	}{
+		{"x : y ; z ;", stmts, ""},
+		{"x : y :", stmts, ""},
+		{"a b", twice, ""},
+		{"a c", twice, `token "c" at offset 2 (token #1): expect "b"`},
+		{"a", twice, `expect "b"`},
+	} {
+		err := runBacktrack(i.Input, i.It)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+
+	// Lookahead alone does not consume.
+	var captured []string
+	err := runBacktrack("a b", func(b *Backtracker) Iteratee {
+		return Seq(b.Lookahead(Match("a")), Capture(Seq(Skip, Skip), appendTo(&captured)), EOF)
+	})
+	if err != nil || len(captured) != 1 || captured[0] != "a b" {
+		t.Errorf("unexpected error %v or captured %q", err, captured)
+	}
+}
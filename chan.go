@@ -0,0 +1,72 @@
+package stream
+
+import "io"
+
+// ChanEnumerator is an Enumerator over tokens received from a channel,
+// which lets a producer goroutine feed an Iteratee directly. Closing
+// the channel marks the end of input. Offsets in errors are those in
+// the concatenation of the tokens.
+type ChanEnumerator struct {
+	ch   <-chan []byte
+	slot tokenSlot
+}
+
+// NewChanEnumerator creates a ChanEnumerator receiving from ch. A token
+// must not be modified after it is sent.
+func NewChanEnumerator(ch <-chan []byte) *ChanEnumerator {
+	return &ChanEnumerator{ch: ch}
+}
+
+func (e *ChanEnumerator) Step(it Iteratee) (Iteratee, error) {
+	next, _, err := e.slot.step(it, func() ([]byte, error) {
+		token, ok := <-e.ch
+		if !ok {
+			return nil, io.EOF
+		}
+		return token, nil
+	})
+	return next, err
+}
+
+// tokenSlot holds the current token of an Enumerator that receives its
+// input one token at a time, e.g. from a channel, and its position.
+type tokenSlot struct {
+	// token is the current token, valid iff have is true.
+	token []byte
+	have  bool
+	// index is the position of the current token and offset is its
+	// byte offset.
+	index, offset int
+}
+
+// step feeds the current token to it, first getting one from fetch if
+// there is none. fetch returns io.EOF at the end of input, where it is
+// given the end of input instead. It also returns whether the token is
+// consumed, after which it is dropped. A token that it fails on is kept.
+func (s *tokenSlot) step(it Iteratee, fetch func() ([]byte, error)) (Iteratee, bool, error) {
+	if !s.have {
+		token, err := fetch()
+		if err == io.EOF {
+			return nil, false, it.Final()
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		s.token, s.have = token, true
+	}
+	next, read, err := it.Next(s.token)
+	if err != nil {
+		return nil, false, WrapTokenErrorAt(s.token, s.offset, s.index, err)
+	}
+	if read {
+		s.index++
+		s.offset += len(s.token)
+		s.drop()
+	}
+	return next, read, nil
+}
+
+// drop discards the current token.
+func (s *tokenSlot) drop() {
+	s.token, s.have = nil, false
+}
@@ -0,0 +1,29 @@
+package stream
+
+import "testing"
+
+func TestChanEnumerator(t *testing.T) {
+	list := Seq(Match("("), Star(Match("x")), Match(")"), EOF)
+	for _, i := range []struct {
+		Input []string
+		Err   string
+	}{
+		{[]string{"(", "x", "x", ")"}, ""},
+		{[]string{"(", "x", "y"}, `token "y" at offset 2 (token #2): expect ")"`},
+		{[]string{"(", "x"}, `expect ")"`},
+	} {
+		ch := make(chan []byte)
+		go func() {
+			for _, s := range i.Input {
+				ch <- []byte(s)
+			}
+			close(ch)
+		}()
+		err := Run(NewChanEnumerator(ch), list)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package stream
+
+// Capture runs it and records copies of the tokens it consumes. When it
+// reaches a final state, fn is called once with the recorded tokens. fn
+// is never called when it fails. Note fn is called as soon as it
+// finishes, even if an enclosing alternative (see Or) fails later.
+func Capture(it Iteratee, fn func(tokens [][]byte)) Iteratee {
+	return captureI{it, nil, fn}
+}
+
+// CaptureTo is like Capture but stores the recorded tokens in *out.
+// Inside a repetition, *out holds the tokens of the last completed
+// repetition.
+func CaptureTo(it Iteratee, out *[][]byte) Iteratee {
+	return Capture(it, func(tokens [][]byte) { *out = tokens })
+}
+
+// captureI implements Capture().
+type captureI struct {
+	A      Iteratee
+	Tokens *tokenList
+	Fn     func([][]byte)
+}
+
+func (it captureI) Final() error {
+	if err := it.A.Final(); err != nil {
+		return err
+	}
+	it.Fn(it.Tokens.slice())
+	return nil
+}
+
+func (it captureI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		return nil, false, err
+	}
+	tokens := it.Tokens
+	if read {
+		tokens = tokens.push(token)
+	}
+	if next == nil {
+		it.Fn(tokens.slice())
+		return nil, read, nil
+	}
+	return captureI{next, tokens, it.Fn}, read, nil
+}
+
+// tokenList is an immutable list of copied tokens, latest first. It
+// allows states holding a tokenList to be shared.
+type tokenList struct {
+	token []byte
+	prev  *tokenList
+	n     int
+}
+
+// push returns a list with a copy of token appended to l.
+func (l *tokenList) push(token []byte) *tokenList {
+	return &tokenList{append([]byte(nil), token...), l, l.len() + 1}
+}
+
+func (l *tokenList) len() int {
+	if l == nil {
+		return 0
+	}
+	return l.n
+}
+
+// slice returns the tokens of l in order.
+func (l *tokenList) slice() [][]byte {
+	if l == nil {
+		return nil
+	}
+	s := make([][]byte, l.n)
+	for i := l.n - 1; l != nil; i, l = i-1, l.prev {
+		s[i] = l.token
+	}
+	return s
+}
+
+// TakeWhile consumes tokens as long as pred returns true on them, and
+// then calls fn with copies of the consumed tokens.
+func TakeWhile(pred func(token []byte) bool, fn func(tokens [][]byte)) Iteratee {
+	return Capture(SkipWhile(pred), fn)
+}
+
+// SkipWhile consumes tokens as long as pred returns true on them. It
+// generalizes SkipAny.
+func SkipWhile(pred func(token []byte) bool) Iteratee {
+	return skipWhileI{pred}
+}
+
+// skipWhileI implements SkipWhile().
+type skipWhileI struct {
+	Pred func([]byte) bool
+}
+
+func (it skipWhileI) Final() error { return nil }
+func (it skipWhileI) Next(token []byte) (Iteratee, bool, error) {
+	if it.Pred(token) {
+		return it, true, nil
+	}
+	return nil, false, nil
+}
+
+// SkipN consumes exactly n tokens.
+func SkipN(n int) Iteratee {
+	return skipNI(n)
+}
+
+// TakeN consumes exactly n tokens and then calls fn with their copies.
+func TakeN(n int, fn func(tokens [][]byte)) Iteratee {
+	return Capture(SkipN(n), fn)
+}
+
+// skipNI implements SkipN(). Its value is the number of tokens left.
+type skipNI int
+
+func (it skipNI) Final() error {
+	if it > 0 {
+		return ErrExpect(plural(int(it), "more token"))
+	}
+	return nil
+}
+
+func (it skipNI) Next(token []byte) (Iteratee, bool, error) {
+	switch {
+	case it <= 0:
+		return nil, false, nil
+	case it == 1:
+		return nil, true, nil
+	}
+	return it - 1, true, nil
+}
+
+// Fold consumes all remaining tokens, accumulating them with f starting
+// from init. At the end of input, done is called with the accumulated
+// value. An error from f is returned as is. Wrap Fold in Filter to
+// reduce only some of the tokens.
+func Fold[A any](init A, f func(acc A, token []byte) (A, error), done func(acc A)) Iteratee {
+	return foldI[A]{init, f, done}
+}
+
+// foldI implements Fold().
+type foldI[A any] struct {
+	Acc  A
+	F    func(A, []byte) (A, error)
+	Done func(A)
+}
+
+func (it foldI[A]) Final() error {
+	it.Done(it.Acc)
+	return nil
+}
+
+func (it foldI[A]) Next(token []byte) (Iteratee, bool, error) {
+	acc, err := it.F(it.Acc, token)
+	if err != nil {
+		return nil, false, err
+	}
+	return foldI[A]{acc, it.F, it.Done}, true, nil
+}
+
+// Counted runs it and counts the tokens it consumes. The count is
+// stored in the returned int each time it reaches a final state.
+func Counted(it Iteratee) (Iteratee, *int) {
+	n := new(int)
+	return countI{it, 0, n}, n
+}
+
+// countI implements Counted().
+type countI struct {
+	A   Iteratee
+	N   int
+	Out *int
+}
+
+func (it countI) Final() error {
+	if err := it.A.Final(); err != nil {
+		return err
+	}
+	*it.Out = it.N
+	return nil
+}
+
+func (it countI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		return nil, false, err
+	}
+	if read {
+		it.N++
+	}
+	if next == nil {
+		*it.Out = it.N
+		return nil, read, nil
+	}
+	it.A = next
+	return it, read, nil
+}
+
+// Bind runs it and then continues with the Iteratee that f computes
+// from copies of the tokens consumed by it. f may return nil to finish
+// immediately, or an error to fail. For example, a length-prefixed
+// payload:
+//
+//	Bind(Skip, func(tokens [][]byte) (Iteratee, error) {
+//		n, err := strconv.Atoi(string(tokens[0]))
+//		if err != nil {
+//			return nil, ErrExpect("length")
+//		}
+//		return SkipN(n), nil
+//	})
+func Bind(it Iteratee, f func(tokens [][]byte) (Iteratee, error)) Iteratee {
+	return bindI{it, nil, f}
+}
+
+// bindI implements Bind().
+type bindI struct {
+	A      Iteratee
+	Tokens *tokenList
+	F      func([][]byte) (Iteratee, error)
+}
+
+func (it bindI) Final() error {
+	if err := it.A.Final(); err != nil {
+		return err
+	}
+	next, err := it.F(it.Tokens.slice())
+	if err != nil || next == nil {
+		return err
+	}
+	return next.Final()
+}
+
+func (it bindI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		return nil, false, err
+	}
+	if read {
+		it.Tokens = it.Tokens.push(token)
+	}
+	if next == nil {
+		next, err := it.F(it.Tokens.slice())
+		if err != nil {
+			return nil, false, err
+		}
+		return next, read, nil
+	}
+	it.A = next
+	return it, read, nil
+}
+
+// Backref runs it and then continues with body(again), where again
+// matches the tokens consumed by it, e.g. a heredoc:
+//
+//	Seq(Match("<<"), Backref(Skip, func(end Iteratee) Iteratee {
+//		return Seq(Star(Seq(Not(end), Skip)), end)
+//	}))
+func Backref(it Iteratee, body func(again Iteratee) Iteratee) Iteratee {
+	return Bind(it, func(tokens [][]byte) (Iteratee, error) {
+		again := make(seqI, len(tokens))
+		for i, t := range tokens {
+			again[i] = Match(string(t))
+		}
+		return body(again), nil
+	})
+}
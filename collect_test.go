@@ -0,0 +1,261 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// appendTo returns a callback that appends the captured tokens to out as
+// a single space separated string.
+func appendTo(out *[]string) func([][]byte) {
+	return func(tokens [][]byte) {
+		s := ""
+		for i, t := range tokens {
+			if i > 0 {
+				s += " "
+			}
+			s += string(t)
+		}
+		*out = append(*out, s)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	atom := Or(Match("a"), Match("b"))
+	for _, i := range []struct {
+		Input    string
+		It       func(fn func([][]byte)) Iteratee
+		Captured []string
+		OK       bool
+	}{
+		{"( a b a )", func(fn func([][]byte)) Iteratee {
+			return Seq(Match("("), Capture(Star(atom), fn), Match(")"), EOF)
+		}, []string{"a b a"}, true},
+		{"( )", func(fn func([][]byte)) Iteratee {
+			return Seq(Match("("), Capture(Star(atom), fn), Match(")"), EOF)
+		}, []string{""}, true},
+		// Once per repetition.
+		{"x a x b b", func(fn func([][]byte)) Iteratee {
+			return Seq(Star(Capture(Seq(Match("x"), Star(atom)), fn)), EOF)
+		}, []string{"x a", "x b b"}, true},
+		// At the end of input.
+		{"a b", func(fn func([][]byte)) Iteratee {
+			return Capture(Star(atom), fn)
+		}, []string{"a b"}, true},
+		// Inside an alternative.
+		{"x y", func(fn func([][]byte)) Iteratee {
+			return Or(Capture(Seq(Match("x"), Match("z")), fn), Capture(Seq(Match("x"), Match("y")), fn))
+		}, []string{"x y"}, true},
+		// No partial capture on error.
+		{"a b c", func(fn func([][]byte)) Iteratee {
+			return Capture(Seq(Match("a"), Match("b"), Match("d")), fn)
+		}, nil, false},
+		{"a b", func(fn func([][]byte)) Iteratee {
+			return Capture(Seq(Match("a"), Match("b"), Match("d")), fn)
+		}, nil, false},
+	} {
+		var captured []string
+		err := runWords(i.Input, i.It(appendTo(&captured)))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+		if !reflect.DeepEqual(captured, i.Captured) {
+			t.Errorf("input %q: expect captured %q; got %q", i.Input, i.Captured, captured)
+		}
+	}
+}
+
+func TestTakeWhile(t *testing.T) {
+	notSemi := func(token []byte) bool { return string(token) != ";" }
+	for _, i := range []struct {
+		Input    string
+		Captured []string
+	}{
+		{"a b ; c ;", []string{"a b", "c", ""}},
+		{"; ;", []string{"", "", ""}},
+		{"a ; b", []string{"a", "b"}},
+	} {
+		var captured []string
+		stmt := TakeWhile(notSemi, appendTo(&captured))
+		if err := runWords(i.Input, Seq(stmt, Star(Seq(Match(";"), stmt)), EOF)); err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		}
+		if !reflect.DeepEqual(captured, i.Captured) {
+			t.Errorf("input %q: expect captured %q; got %q", i.Input, i.Captured, captured)
+		}
+	}
+}
+
+func TestSkipWhile(t *testing.T) {
+	blank := func(token []byte) bool { return len(token) == 0 }
+	lines := func(s string) error {
+		return Run(NewScanEnumeratorWith(strings.NewReader(s), bufio.ScanLines), Seq(SkipWhile(blank), Match("x"), SkipWhile(blank), EOF))
+	}
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"x", true},
+		{"\n\nx\n\n\n", true},
+		{"\n\ny\n", false},
+		{"\nx\n\nx", false},
+	} {
+		err := lines(i.Input)
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+}
+
+func TestTakeN(t *testing.T) {
+	for _, i := range []struct {
+		Input    string
+		N        int
+		Captured []string
+		Err      string
+	}{
+		{"a b c", 3, []string{"a b c"}, ""},
+		{"a b c", 0, []string{""}, `token "a" at offset 0 (token #0): expect <eof>`},
+		{"a b c", 2, []string{"a b"}, `token "c" at offset 4 (token #2): expect <eof>`},
+		{"a b c", 5, nil, "expect 2 more tokens"},
+		{"a b c", 4, nil, "expect 1 more token"},
+	} {
+		var captured []string
+		err := runWords(i.Input, Seq(TakeN(i.N, appendTo(&captured)), EOF))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if !reflect.DeepEqual(captured, i.Captured) {
+			t.Errorf("input %q: expect captured %q; got %q", i.Input, i.Captured, captured)
+		}
+	}
+
+	if err := runWords("a b c", Seq(SkipN(2), Match("c"), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestFold(t *testing.T) {
+	sum := func(acc int, token []byte) (int, error) {
+		n, err := strconv.Atoi(string(token))
+		return acc + n, err
+	}
+	for _, i := range []struct {
+		Input string
+		Sum   int
+		OK    bool
+	}{
+		{"", 0, true},
+		{"1 2 3", 6, true},
+		{"1 x 3", -1, false},
+	} {
+		total := -1
+		err := runWords(i.Input, Fold(0, sum, func(n int) { total = n }))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+		if total != i.Sum {
+			t.Errorf("input %q: expect %d; got %d", i.Input, i.Sum, total)
+		}
+	}
+}
+
+func TestCaptureTo(t *testing.T) {
+	var key, value [][]byte
+	field := Seq(CaptureTo(Skip, &key), Match("="), CaptureTo(Star(Seq(Not(Match(";")), Skip)), &value), Match(";"))
+	if err := runWords("name = John Smith ;", Seq(field, EOF)); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if k, v := string(bytes.Join(key, nil)), string(bytes.Join(value, []byte(" "))); k != "name" || v != "John Smith" {
+		t.Errorf("unexpected key %q and value %q", k, v)
+	}
+}
+
+func TestCounted(t *testing.T) {
+	body, n := Counted(Star(Match("x")))
+	for _, i := range []struct {
+		Input string
+		N     int
+	}{
+		{"{ x x }", 2},
+		{"{ }", 0},
+		{"{ x x x x x }", 5},
+	} {
+		if err := runWords(i.Input, Seq(Match("{"), body, Match("}"), EOF)); err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		}
+		if *n != i.N {
+			t.Errorf("input %q: expect %d; got %d", i.Input, i.N, *n)
+		}
+	}
+}
+
+func TestBackref(t *testing.T) {
+	heredoc := Seq(Match("<<"), Backref(Skip, func(end Iteratee) Iteratee {
+		return Seq(Star(Seq(Not(end), Skip)), end)
+	}))
+	tag := Rec(func(tag Iteratee) Iteratee {
+		return Seq(Match("<"), Backref(Skip, func(name Iteratee) Iteratee {
+			return Seq(Match(">"), Star(Or(Match("text"), tag)), Match("</"), name, Match(">"))
+		}))
+	})
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		OK    bool
+	}{
+		{"<< END a b END", heredoc, true},
+		{"<< END END", heredoc, true},
+		{"<< END a b EOF", heredoc, false},
+		{"<< END a b", heredoc, false},
+		{"< a > text < b > </ b > </ a >", tag, true},
+		{"< a > text < b > </ a > </ b >", tag, false},
+	} {
+		err := runWords(i.Input, Seq(i.It, EOF))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	frame := Bind(Skip, func(tokens [][]byte) (Iteratee, error) {
+		n, err := strconv.Atoi(string(tokens[0]))
+		if err != nil {
+			return nil, ErrExpect("length")
+		}
+		return SkipN(n), nil
+	})
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		Err   string
+	}{
+		{"2 a b 0 1 c", Seq(Star(frame), EOF), ""},
+		{"", Seq(Star(frame), EOF), ""},
+		{"3 a b", frame, "expect 1 more token"},
+		{"x a b", frame, `token "x" at offset 0 (token #0): expect length`},
+		{"1 a b", Seq(frame, frame), `token "b" at offset 4 (token #2): expect length`},
+	} {
+		err := runWords(i.Input, i.It)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SplitFuncState is a SplitState that splits with a stateless
+// bufio.SplitFunc, e.g. to put one behind a LineComment.
+type SplitFuncState bufio.SplitFunc
+
+func (s SplitFuncState) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	advance, token, err := s(data, atEOF)
+	return s, advance, token, err
+}
+
+// LineComment is a SplitState that skips the comments that start with
+// Prefix (e.g. "#" or "//") and run to the end of the line, and splits
+// the rest of the input with Inner, e.g.
+//
+//	StatefulSplitFunc(LineComment{"#", SplitFuncState(bufio.ScanWords)})
+//
+// A comment starts where a token could: after any space, which is
+// skipped, so Inner must ignore leading space, and Inner is only asked
+// for a token from where a comment could start. A comment is skipped as
+// it is read, so it may be longer than the buffer.
+type LineComment struct {
+	Prefix string
+	Inner  SplitState
+}
+
+func (s LineComment) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	i, match := 0, commentNo
+	if !inComment(s.Inner) {
+		i, match = skipToComment(data, atEOF, s.Prefix)
+	}
+	switch match {
+	case commentMore:
+		return s, i, nil, nil
+	case commentYes:
+		return lineCommentIn{s}, i + len(s.Prefix), nil, nil
+	}
+	next, advance, token, err := s.Inner.Next(data, atEOF)
+	s.Inner = next
+	return s, advance, token, err
+}
+
+// lineCommentIn is the state of LineComment inside a comment.
+type lineCommentIn struct {
+	S LineComment
+}
+
+func (s lineCommentIn) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return s.S, i + 1, nil, nil
+	}
+	return s, len(data), nil, nil
+}
+
+// BlockComment is a SplitState that skips the comments between Open and
+// Close (e.g. "/*" and "*/") and splits the rest of the input with Inner.
+// If Nested is true, comments nest, as in "/* a /* b */ c */". A comment
+// starts like in LineComment. A comment left open at the end of input
+// fails with ErrUnclosedComment.
+type BlockComment struct {
+	Open, Close string
+	Nested      bool
+	Inner       SplitState
+}
+
+func (s BlockComment) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	i, match := 0, commentNo
+	if !inComment(s.Inner) {
+		i, match = skipToComment(data, atEOF, s.Open)
+	}
+	switch match {
+	case commentMore:
+		return s, i, nil, nil
+	case commentYes:
+		return blockCommentIn{s, 1}, i + len(s.Open), nil, nil
+	}
+	next, advance, token, err := s.Inner.Next(data, atEOF)
+	s.Inner = next
+	return s, advance, token, err
+}
+
+// blockCommentIn is the state of BlockComment inside Depth nested
+// comments.
+type blockCommentIn struct {
+	S     BlockComment
+	Depth int
+}
+
+func (s blockCommentIn) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	open, close := []byte(s.S.Open), []byte(s.S.Close)
+	i := 0
+	for {
+		c := bytes.Index(data[i:], close)
+		o := -1
+		if s.S.Nested {
+			o = bytes.Index(data[i:], open)
+		}
+		switch {
+		case o >= 0 && (c < 0 || o < c):
+			s.Depth++
+			i += o + len(open)
+		case c >= 0:
+			s.Depth--
+			i += c + len(close)
+			if s.Depth == 0 {
+				return s.S, i, nil, nil
+			}
+		case atEOF:
+			return s, 0, nil, ErrUnclosedComment(s.S.Open)
+		default:
+			// Keep what may be the start of a delimiter.
+			keep := max(len(open), len(close)) - 1
+			if n := len(data) - keep; n > i {
+				i = n
+			}
+			return s, i, nil, nil
+		}
+	}
+}
+
+// inComment tells whether s is inside a comment, in which case the
+// comment splitters that wrap it pass the input through.
+func inComment(s SplitState) bool {
+	switch s := s.(type) {
+	case lineCommentIn, blockCommentIn:
+		return true
+	case LineComment:
+		return inComment(s.Inner)
+	case BlockComment:
+		return inComment(s.Inner)
+	}
+	return false
+}
+
+// ErrUnclosedComment reports a block comment left open at the end of
+// input.
+type ErrUnclosedComment string
+
+func (e ErrUnclosedComment) Error() string { return fmt.Sprintf("unclosed comment %q", string(e)) }
+
+// Results of skipToComment.
+const (
+	commentNo   = iota // no comment here.
+	commentYes         // a comment starts here.
+	commentMore        // more input is needed to tell.
+)
+
+// skipToComment skips the space at the start of data and tells whether
+// a comment starting with prefix follows. It returns the size of the
+// space.
+func skipToComment(data []byte, atEOF bool, prefix string) (int, int) {
+	i := 0
+	for i < len(data) {
+		r, size := utf8.DecodeRune(data[i:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		i += size
+	}
+	rest := data[i:]
+	switch {
+	case bytes.HasPrefix(rest, []byte(prefix)):
+		return i, commentYes
+	case !atEOF && (len(rest) == 0 || bytes.HasPrefix([]byte(prefix), rest)):
+		return i, commentMore
+	}
+	return i, commentNo
+}
@@ -0,0 +1,41 @@
+package stream
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestComments(t *testing.T) {
+	words := SplitFuncState(bufio.ScanWords)
+	for _, i := range []struct {
+		Input  string
+		Tokens string
+		Err    string
+	}{
+		{"a // b c\nd # e\n  f", "a|d|f", ""},
+		{"a /* b /* c */ d */ e", "a|e", ""},
+		{"a/b /**/ c*/d", "a/b|c*/d", ""},
+		{"a /* b /* c */", "a", `unclosed comment "/*"`},
+		{"a // b", "a", ""},
+		{"a /", "a|/", ""},
+		{"a /* # */ b # /*\nc", "a|b|c", ""},
+	} {
+		split := StatefulSplitFunc(LineComment{"//", BlockComment{"/*", "*/", true, LineComment{"#", words}}})
+		tokens, err := splitAll(i.Input, split)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+
+	// Comments do not nest unless asked to.
+	split := StatefulSplitFunc(BlockComment{"(*", "*)", false, words})
+	if tokens, err := splitAll("a (* b (* c *) d *) e", split); err != nil || strings.Join(tokens, "|") != "a|d|*)|e" {
+		t.Errorf("expect %q; got %q, %v", "a|d|*)|e", tokens, err)
+	}
+}
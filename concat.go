@@ -0,0 +1,59 @@
+package stream
+
+import "io"
+
+// Concat returns an Enumerator that exhausts each of es in order, as if
+// they were a single input: the Iteratee is only given the end of input
+// (see Iteratee.Final) after the last one. Positions in errors are those
+// within the source of the token.
+func Concat(es ...Enumerator) Enumerator {
+	return &concatEnumerator{es: es}
+}
+
+// concatEnumerator implements Concat(). When not nil, more is called
+// for another source after es is exhausted, until it returns io.EOF.
+// ended is set when the current source reaches the end of input.
+type concatEnumerator struct {
+	es    []Enumerator
+	more  func() (Enumerator, error)
+	ended bool
+}
+
+func (e *concatEnumerator) Step(it Iteratee) (Iteratee, error) {
+	for {
+		if len(e.es) == 0 {
+			if e.more == nil {
+				break
+			}
+			next, err := e.more()
+			if err == io.EOF {
+				e.more = nil
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			e.es = append(e.es, next)
+		}
+		e.ended = false
+		next, err := e.es[0].Step(concatI{it, e})
+		if err != nil || !e.ended {
+			return next, err
+		}
+		e.es = e.es[1:]
+	}
+	return nil, it.Final()
+}
+
+// concatI hides the end of each source from A.
+type concatI struct {
+	A Iteratee
+	E *concatEnumerator
+}
+
+func (it concatI) Final() error {
+	it.E.ended = true
+	return nil
+}
+
+func (it concatI) Next(token []byte) (Iteratee, bool, error) { return it.A.Next(token) }
@@ -0,0 +1,36 @@
+package stream
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestConcat(t *testing.T) {
+	words := func(s string) Enumerator {
+		return NewScanEnumeratorWith(strings.NewReader(s), bufio.ScanWords)
+	}
+	list := Seq(Match("("), Star(Match("x")), Match(")"), EOF)
+	for _, i := range []struct {
+		Input []string
+		Err   string
+	}{
+		{[]string{"( x", "x )"}, ""},
+		{[]string{"(", "", "x", ")"}, ""},
+		{[]string{"( x )"}, ""},
+		{[]string{"( x", "x"}, `expect ")"`},
+		{[]string{"( x", "y )"}, `token "y" at offset 0 (token #0): expect ")"`},
+		{nil, `expect "("`},
+	} {
+		es := make([]Enumerator, len(i.Input))
+		for k, s := range i.Input {
+			es[k] = words(s)
+		}
+		err := Run(Concat(es...), list)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ConnEnumerator is a ScanEnumerator reading from a net.Conn. A clean
+// end of input from the peer is the end of input for the Iteratee,
+// while a read error, such as a timeout or a reset connection, is
+// reported as a ConnErr.
+type ConnEnumerator struct {
+	*ScanEnumerator
+	r *connReader
+}
+
+// NewConnEnumerator creates a ConnEnumerator reading from c and
+// splitting it with split. When timeout > 0, a read fails with a timeout
+// when no data arrives within timeout.
+func NewConnEnumerator(c net.Conn, split bufio.SplitFunc, timeout time.Duration, opts ...ScanOption) *ConnEnumerator {
+	r := &connReader{c: c, timeout: timeout}
+	return &ConnEnumerator{newScanEnumerator(r, split, newScanConfig(opts)), r}
+}
+
+func (e *ConnEnumerator) Step(it Iteratee) (Iteratee, error) {
+	next, err := e.ScanEnumerator.Step(it)
+	if err != nil && e.failed {
+		err = connError(err)
+	}
+	return next, err
+}
+
+// SetDeadline sets a deadline for all future reads, in addition to the
+// timeout of each read. It makes ConnEnumerator a Deadliner.
+func (e *ConnEnumerator) SetDeadline(t time.Time) error {
+	e.r.deadline = t
+	return e.r.c.SetReadDeadline(t)
+}
+
+// connReader sets the read deadline of c before each read.
+type connReader struct {
+	c        net.Conn
+	timeout  time.Duration
+	deadline time.Time
+}
+
+func (r *connReader) Read(p []byte) (int, error) {
+	if r.timeout > 0 {
+		t := time.Now().Add(r.timeout)
+		if !r.deadline.IsZero() && r.deadline.Before(t) {
+			t = r.deadline
+		}
+		if err := r.c.SetReadDeadline(t); err != nil {
+			return 0, err
+		}
+	}
+	return r.c.Read(p)
+}
+
+// ConnErr reports a failed read from a connection.
+type ConnErr struct {
+	Timeout bool // the read timed out.
+	Reset   bool // the connection was reset by the peer.
+	Err     error
+}
+
+func (e ConnErr) Error() string {
+	switch {
+	case e.Timeout:
+		return "read timed out: " + e.Err.Error()
+	case e.Reset:
+		return "connection reset: " + e.Err.Error()
+	}
+	return "read: " + e.Err.Error()
+}
+
+func (e ConnErr) Unwrap() error { return e.Err }
+
+func connError(err error) error {
+	return ConnErr{errors.Is(err, os.ErrDeadlineExceeded), errors.Is(err, syscall.ECONNRESET), err}
+}
@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// failConn is a net.Conn whose reads fail with Err.
+type failConn struct {
+	net.Conn
+	Err error
+}
+
+func (c failConn) Read([]byte) (int, error) { return 0, c.Err }
+
+// listErr is an error that is not comparable.
+type listErr []string
+
+func (e listErr) Error() string { return e[0] }
+
+func TestConnEnumerator(t *testing.T) {
+	list := Seq(Match("("), Star(Match("x")), Match(")"), EOF)
+
+	// A clean close is the end of input.
+	client, server := net.Pipe()
+	go func() {
+		client.Write([]byte("( x x )"))
+		client.Close()
+	}()
+	if err := Run(NewConnEnumerator(server, bufio.ScanWords, 0), list); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// A stalled peer times out.
+	client, server = net.Pipe()
+	defer client.Close()
+	go client.Write([]byte("( x "))
+	err := Run(NewConnEnumerator(server, bufio.ScanWords, 10*time.Millisecond), list)
+	if ce, ok := err.(ConnErr); !ok || !ce.Timeout || ce.Reset {
+		t.Errorf("expect timeout; got %v", err)
+	}
+
+	// An oversized token.
+	client, server = net.Pipe()
+	defer client.Close()
+	go client.Write([]byte("( xxxxxxxx )"))
+	err = Run(NewConnEnumerator(server, bufio.ScanWords, 0, MaxTokenSize(4)), list)
+	if ce, ok := err.(ConnErr); !ok || ce.Timeout || !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("expect bufio.ErrTooLong; got %v", err)
+	}
+
+	// Any read error is reported, comparable or not.
+	client, server = net.Pipe()
+	defer client.Close()
+	err = Run(NewConnEnumerator(failConn{server, listErr{"boom"}}, bufio.ScanWords, 0), list)
+	if ce, ok := err.(ConnErr); !ok || ce.Error() != "read: boom" {
+		t.Errorf("expect ConnErr; got %v", err)
+	}
+
+	// Cancelled by a context.
+	client, server = net.Pipe()
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := Run(WithContext(ctx, NewConnEnumerator(server, bufio.ScanWords, 0)), list); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expect context.DeadlineExceeded; got %v", err)
+	}
+	// The connection is still usable afterwards.
+	go client.Write([]byte("y"))
+	buf := make([]byte, 1)
+	if n, err := server.Read(buf); err != nil || string(buf[:n]) != "y" {
+		t.Errorf("expect to read %q after the run; got %q, %v", "y", buf[:n], err)
+	}
+}
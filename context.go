@@ -0,0 +1,128 @@
+package stream
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ContextStepper is implemented by Enumerators that can stop waiting
+// for input when a context is done.
+type ContextStepper interface {
+	StepContext(ctx context.Context, it Iteratee) (Iteratee, error)
+}
+
+// Deadliner is implemented by Enumerators whose reads can be given a
+// deadline, e.g. those reading from a net.Conn.
+type Deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// WithContext returns an Enumerator that fails with ctx.Err() once ctx
+// is done. A ContextStepper e is stepped with ctx, and a Deadliner e is
+// given the deadline of ctx and interrupted when ctx is cancelled. For
+// other Enumerators, ctx is only checked between steps. An error of the
+// Iteratee is reported as is, even when ctx is done by then.
+func WithContext(ctx context.Context, e Enumerator) Enumerator {
+	return &ctxEnumerator{ctx: ctx, e: e}
+}
+
+// ctxEnumerator implements WithContext(). armed is true once the
+// deadline of Deadliner d has been set up, and stop unregisters its
+// interruption, which closes interrupted once done. failed is set when
+// the Iteratee returns an error.
+type ctxEnumerator struct {
+	ctx         context.Context
+	e           Enumerator
+	armed       bool
+	d           Deadliner
+	stop        func() bool
+	interrupted chan struct{}
+	failed      bool
+}
+
+func (e *ctxEnumerator) Step(it Iteratee) (Iteratee, error) {
+	next, err := e.step(it)
+	if (err != nil || next == nil) && e.stop != nil {
+		if !e.stop() {
+			<-e.interrupted
+		}
+		e.stop = nil
+		// Leave the Deadliner, e.g. a connection, usable after the run.
+		e.d.SetDeadline(time.Time{})
+	}
+	return next, err
+}
+
+func (e *ctxEnumerator) step(it Iteratee) (Iteratee, error) {
+	if err := e.ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s, ok := e.e.(ContextStepper); ok {
+		return s.StepContext(e.ctx, it)
+	}
+	if d, ok := e.e.(Deadliner); ok && !e.armed {
+		e.armed = true
+		if t, ok := e.ctx.Deadline(); ok {
+			if err := d.SetDeadline(t); err != nil {
+				return nil, err
+			}
+		}
+		e.d, e.interrupted = d, make(chan struct{})
+		e.stop = context.AfterFunc(e.ctx, func() {
+			d.SetDeadline(time.Unix(1, 0))
+			close(e.interrupted)
+		})
+	}
+	e.failed = false
+	next, err := e.e.Step(ctxI{it, e})
+	if err != nil && !e.failed {
+		// Report the cancellation rather than the interrupted read. The
+		// read may time out just before ctx notices its deadline.
+		if cerr := e.ctx.Err(); cerr != nil {
+			err = cerr
+		} else if t, ok := e.ctx.Deadline(); ok && !time.Now().Before(t) {
+			err = context.DeadlineExceeded
+		}
+	}
+	return next, err
+}
+
+// ctxI tells E when A returns an error.
+type ctxI struct {
+	A Iteratee
+	E *ctxEnumerator
+}
+
+func (it ctxI) Final() error {
+	err := it.A.Final()
+	if err != nil {
+		it.E.failed = true
+	}
+	return err
+}
+
+func (it ctxI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		it.E.failed = true
+	}
+	return next, read, err
+}
+
+// StepContext is like Step but returns ctx.Err() when ctx is done
+// before a token is received.
+func (e *ChanEnumerator) StepContext(ctx context.Context, it Iteratee) (Iteratee, error) {
+	next, _, err := e.slot.step(it, func() ([]byte, error) {
+		select {
+		case token, ok := <-e.ch:
+			if !ok {
+				return nil, io.EOF
+			}
+			return token, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+	return next, err
+}
@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// pipeEnumerator reads tokens from a blocking channel, and gives up
+// reading when its deadline passes.
+type pipeEnumerator struct {
+	ch       chan []byte
+	deadline chan time.Time
+}
+
+func (e *pipeEnumerator) SetDeadline(t time.Time) error {
+	e.deadline <- t
+	return nil
+}
+
+func (e *pipeEnumerator) Step(it Iteratee) (Iteratee, error) {
+	var timer <-chan time.Time
+	for {
+		select {
+		case token := <-e.ch:
+			next, read, err := it.Next(token)
+			if err != nil || read {
+				return next, err
+			}
+			it = next
+		case t := <-e.deadline:
+			timer = time.After(time.Until(t))
+		case <-timer:
+			return nil, io.ErrNoProgress
+		}
+	}
+}
+
+// cancelEnumerator cancels a context and then presents token once.
+type cancelEnumerator struct {
+	cancel func()
+	token  []byte
+}
+
+func (e cancelEnumerator) Step(it Iteratee) (Iteratee, error) {
+	e.cancel()
+	next, _, err := it.Next(e.token)
+	return next, WrapTokenError(e.token, err)
+}
+
+func TestWithContext(t *testing.T) {
+	ch := make(chan []byte, 1)
+	ch <- []byte("x")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- Run(WithContext(ctx, NewChanEnumerator(ch)), Star(Match("x"))) }()
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expect context.Canceled; got %v", err)
+	}
+
+	p := &pipeEnumerator{make(chan []byte, 1), make(chan time.Time, 2)}
+	p.ch <- []byte("x")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := Run(WithContext(ctx, p), Star(Match("x"))); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expect context.DeadlineExceeded; got %v", err)
+	}
+
+	// An error of the Iteratee is not hidden by ctx.
+	ctx, cancel = context.WithCancel(context.Background())
+	if err := Run(WithContext(ctx, cancelEnumerator{cancel, []byte("y")}), Match("x")); !errors.Is(err, ErrExpectQ("x")) {
+		t.Errorf("expect ErrExpectQ; got %v", err)
+	}
+
+	// The interruption is unregistered and the deadline cleared once the
+	// run is over.
+	p = &pipeEnumerator{make(chan []byte, 1), make(chan time.Time, 2)}
+	p.ch <- []byte("x")
+	ctx, cancel = context.WithCancel(context.Background())
+	if err := Run(WithContext(ctx, p), Match("x")); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	if len(p.deadline) != 1 {
+		t.Errorf("expect only the deadline cleared after the run; got %d deadlines", len(p.deadline))
+	} else if d := <-p.deadline; !d.IsZero() {
+		t.Errorf("expect the deadline cleared after the run; got %v", d)
+	}
+
+	// Nothing changes when ctx is never done.
+	if err := Run(WithContext(context.Background(), NewStringsEnumerator([]string{"x", "x"})), Seq(Star(Match("x")), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+)
+
+// RecordSep is the token that ends each record in enumerators of
+// records and fields, e.g. NewCSVEnumerator. It is the ASCII record
+// separator, which is then ambiguous with a field of the same value.
+const RecordSep = "\x1e"
+
+// EndOfRecord matches RecordSep.
+var EndOfRecord = Match(RecordSep)
+
+// NewCSVEnumerator creates an Enumerator over the records read by r,
+// which may be configured beforehand (e.g. its Comma), with one token
+// per field followed by RecordSep. Quoting and newlines inside fields
+// are handled by r, whose errors (e.g. *csv.ParseError) are returned
+// as is.
+func NewCSVEnumerator(r *csv.Reader) Enumerator {
+	return &csvEnumerator{r: r}
+}
+
+// csvEnumerator implements NewCSVEnumerator(). pending holds the tokens
+// of the current record not consumed yet and index is the position of
+// the current token.
+type csvEnumerator struct {
+	r       *csv.Reader
+	pending [][]byte
+	index   int
+}
+
+func (e *csvEnumerator) Step(it Iteratee) (Iteratee, error) {
+	if len(e.pending) == 0 {
+		record, err := e.r.Read()
+		if err == io.EOF {
+			return nil, it.Final()
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range record {
+			e.pending = append(e.pending, []byte(f))
+		}
+		e.pending = append(e.pending, []byte(RecordSep))
+	}
+	token := e.pending[0]
+	next, read, err := it.Next(token)
+	if err != nil {
+		return nil, WrapTokenErrorAt(token, -1, e.index, err)
+	}
+	if read {
+		e.pending = e.pending[1:]
+		e.index++
+	}
+	return next, nil
+}
+
+// CSVSplit is a SplitState for CSV (RFC 4180) that makes the same tokens
+// as NewCSVEnumerator, one per field followed by RecordSep, without
+// allocating whole records. Comma is the field delimiter, or ',' if
+// zero. Empty lines are skipped and "\r\n" ends a line like "\n". A
+// quote that is not allowed fails with csv.ErrBareQuote or
+// csv.ErrQuote, as with a csv.Reader without LazyQuotes.
+type CSVSplit struct {
+	Comma byte
+}
+
+func (s CSVSplit) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	i := 0
+	for i < len(data) && (data[i] == '\n' || data[i] == '\r' && (i+1 < len(data) && data[i+1] == '\n')) {
+		i++
+	}
+	if i == len(data) || data[i] == '\r' && i+1 == len(data) && !atEOF {
+		return s, i, nil, nil
+	}
+	next, advance, token, err := csvField{s}.Next(data[i:], atEOF)
+	if advance == 0 && token == nil {
+		return s, i, nil, err
+	}
+	return next, i + advance, token, err
+}
+
+// csvField is the state of CSVSplit at the start of a field that is not
+// the first one of its record.
+type csvField struct {
+	S CSVSplit
+}
+
+func (s csvField) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	comma := s.S.Comma
+	if comma == 0 {
+		comma = ','
+	}
+	var (
+		end, next int // the end of the field and the start of what follows.
+		field     []byte
+	)
+	if len(data) > 0 && data[0] == '"' {
+		end = -1
+		for i := 1; i < len(data); i++ {
+			if data[i] != '"' {
+				continue
+			}
+			if i+1 == len(data) && !atEOF {
+				return s, 0, nil, nil
+			}
+			if i+1 < len(data) && data[i+1] == '"' {
+				i++
+				continue
+			}
+			end = i + 1
+			break
+		}
+		if end < 0 {
+			if atEOF {
+				return s, 0, nil, csv.ErrQuote
+			}
+			return s, 0, nil, nil
+		}
+		field = bytes.ReplaceAll(data[1:end-1], []byte(`""`), []byte(`"`))
+		field = bytes.ReplaceAll(field, []byte("\r\n"), []byte("\n"))
+		if field == nil {
+			// An empty field is still a token.
+			field = data[:0]
+		}
+		switch {
+		case end == len(data) && !atEOF, end+1 == len(data) && data[end] == '\r' && !atEOF:
+			return s, 0, nil, nil
+		case end == len(data), data[end] == comma, data[end] == '\n':
+		case data[end] == '\r' && (end+1 == len(data) || data[end+1] == '\n'):
+			end++
+		default:
+			return s, 0, nil, csv.ErrQuote
+		}
+	} else {
+		end = bytes.IndexAny(data, string([]byte{comma, '\n'}))
+		if end < 0 && !atEOF {
+			return s, 0, nil, nil
+		}
+		if end < 0 {
+			end = len(data)
+		}
+		field = data[:end]
+		if end < len(data) && data[end] == '\n' {
+			field = bytes.TrimSuffix(field, []byte("\r"))
+		}
+		if bytes.IndexByte(field, '"') >= 0 {
+			return s, 0, nil, csv.ErrBareQuote
+		}
+	}
+	next = end
+	if end < len(data) {
+		next++
+		if data[end] == comma {
+			return s, next, field, nil
+		}
+	}
+	return csvEnd{s.S}, next, field, nil
+}
+
+// csvEnd is the state of CSVSplit at the end of a record.
+type csvEnd struct {
+	S CSVSplit
+}
+
+func (s csvEnd) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	return s.S, 0, []byte(RecordSep), nil
+}
@@ -0,0 +1,72 @@
+package stream
+
+import (
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCSVEnumerator(t *testing.T) {
+	var fields []string
+	field := Capture(Seq(Not(EndOfRecord), Skip), appendTo(&fields))
+	table := Seq(Star(Seq(field, Star(field), EndOfRecord)), EOF)
+	for _, i := range []struct {
+		Input  string
+		Fields []string
+		Err    error
+	}{
+		{"a,b\n\"c,d\",\"e\nf\"\n", []string{"a", "b", "c,d", "e\nf"}, nil},
+		{"a,\"\"\"b\"\"\"\r\n", []string{"a", `"b"`}, nil},
+		{"", nil, nil},
+		{"a,\"b\n", nil, csv.ErrQuote},
+	} {
+		fields = nil
+		err := Run(NewCSVEnumerator(csv.NewReader(strings.NewReader(i.Input))), table)
+		if !errors.Is(err, i.Err) {
+			t.Errorf("input %q: expect error %v; got %v", i.Input, i.Err, err)
+		}
+		if strings.Join(fields, "|") != strings.Join(i.Fields, "|") {
+			t.Errorf("input %q: expect fields %q; got %q", i.Input, i.Fields, fields)
+		}
+	}
+
+	// Records of exactly two fields.
+	r := csv.NewReader(strings.NewReader("a;b\nc;d\n"))
+	r.Comma = ';'
+	pair := Seq(Skip, Skip, EndOfRecord)
+	if err := Run(NewCSVEnumerator(r), Seq(Star(pair), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	err := Run(NewCSVEnumerator(csv.NewReader(strings.NewReader("a,b\nc\n"))), Seq(Star(pair), EOF))
+	if !errors.Is(err, csv.ErrFieldCount) {
+		t.Errorf("expect csv.ErrFieldCount; got %v", err)
+	}
+}
+
+func TestCSVSplit(t *testing.T) {
+	rs := strings.ReplaceAll(RecordSep, "\x1e", "/")
+	for _, i := range []struct {
+		Input  string
+		Comma  byte
+		Tokens string
+		Err    error
+	}{
+		{"a,b\n\"c,d\",\"e\r\nf\"\n", 0, "a|b|/|c,d|e\nf|/", nil},
+		{"a,\"\"\"b\"\"\"\r\n\r\n\nc,", 0, `a|"b"|/|c||/`, nil},
+		{"a;b\nc", ';', "a|b|/|c|/", nil},
+		{"", 0, "", nil},
+		{"\"\"", 0, "|/", nil},
+		{"a,\"b\n", 0, "a", csv.ErrQuote},
+		{"a,\"b\"c\n", 0, "a", csv.ErrQuote},
+		{"a,b\"c\n", 0, "a", csv.ErrBareQuote},
+	} {
+		tokens, err := splitAll(i.Input, StatefulSplitFunc(CSVSplit{i.Comma}))
+		if !errors.Is(err, i.Err) {
+			t.Errorf("input %q: expect error %v; got %v", i.Input, i.Err, err)
+		}
+		if got := strings.ReplaceAll(strings.Join(tokens, "|"), RecordSep, rs); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+}
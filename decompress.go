@@ -0,0 +1,73 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+)
+
+// NewDecompressingEnumerator creates a ScanEnumerator reading from r,
+// which is decompressed first when it starts with the magic bytes of
+// one of decoders, or else of gzip or bzip2. Input in no known format is
+// read as is. E.g. with github.com/klauspost/compress/zstd:
+//
+//	zstdDecoder := stream.Decompressor{
+//		Magic: "\x28\xb5\x2f\xfd",
+//		NewReader: func(r io.Reader) (io.Reader, error) {
+//			return zstd.NewReader(r)
+//		},
+//	}
+//	e := stream.NewDecompressingEnumerator(r, bufio.ScanLines, zstdDecoder)
+func NewDecompressingEnumerator(r io.Reader, split bufio.SplitFunc, decoders ...Decompressor) *ScanEnumerator {
+	return NewScanEnumeratorWith(&sniffReader{r: bufio.NewReader(r), decoders: decoders}, split)
+}
+
+// Decompressor is a compression format whose data starts with Magic.
+// NewReader creates a reader of the decompressed data.
+type Decompressor struct {
+	Magic     string
+	NewReader func(io.Reader) (io.Reader, error)
+}
+
+// defaultDecompressors are tried after those given to
+// NewDecompressingEnumerator.
+var defaultDecompressors = []Decompressor{
+	{"\x1f\x8b", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+	{"BZh", func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r), nil }},
+}
+
+// sniffReader chooses the decompressor on its first read, so that
+// errors are reported by ScanEnumerator.Step.
+type sniffReader struct {
+	r        *bufio.Reader
+	decoders []Decompressor
+	in       io.Reader // nil until the format is known.
+	err      error
+}
+
+func (s *sniffReader) Read(p []byte) (int, error) {
+	if s.in == nil && s.err == nil {
+		s.in, s.err = s.sniff()
+	}
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.in.Read(p)
+}
+
+func (s *sniffReader) sniff() (io.Reader, error) {
+	for _, list := range [][]Decompressor{s.decoders, defaultDecompressors} {
+		for _, d := range list {
+			head, err := s.r.Peek(len(d.Magic))
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			if bytes.Equal(head, []byte(d.Magic)) {
+				return d.NewReader(s.r)
+			}
+		}
+	}
+	return s.r, nil
+}
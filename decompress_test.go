@@ -0,0 +1,49 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"testing"
+)
+
+func TestDecompressingEnumerator(t *testing.T) {
+	const text = "( x x )"
+	gz := new(bytes.Buffer)
+	w := gzip.NewWriter(gz)
+	io.WriteString(w, text)
+	w.Close()
+	// bzip2 of "( x x )", as compress/bzip2 has no writer.
+	bz := "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x44\xe1\x16\x02\x00\x00\x01\x90\x80\x40\x60\x00\x40\x20\x00\x21\x9a\x68\x33\x4d\x19\x96\xf1\x77\x24\x53\x85\x09\x04\x4e\x11\x60\x20"
+	zl := new(bytes.Buffer)
+	zw := zlib.NewWriter(zl)
+	io.WriteString(zw, text)
+	zw.Close()
+
+	list := Seq(Match("("), Star(Match("x")), Match(")"), EOF)
+	for _, i := range []struct {
+		Name, Input string
+		OK          bool
+	}{
+		{"plain", text, true},
+		{"empty", "", false},
+		{"gzip", gz.String(), true},
+		{"bzip2", bz, true},
+		{"truncated gzip", gz.String()[:gz.Len()-4], false},
+		{"unregistered zlib", zl.String(), false},
+	} {
+		err := Run(NewDecompressingEnumerator(bytes.NewReader([]byte(i.Input)), bufio.ScanWords), list)
+		if i.OK && err != nil {
+			t.Errorf("%s: unexpected error %v", i.Name, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("%s: expect error", i.Name)
+		}
+	}
+
+	zlibDecoder := Decompressor{"\x78\x9c", func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }}
+	if err := Run(NewDecompressingEnumerator(bytes.NewReader(zl.Bytes()), bufio.ScanWords, zlibDecoder), list); err != nil {
+		t.Errorf("zlib: unexpected error %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package stream
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Between runs open, body and close in sequence. When close fails, the
+// error is reported as an UnclosedErr that locates the opening
+// delimiter by its distance from the failing token.
+func Between(open, body, close Iteratee) Iteratee {
+	return betweenI{open, nil, body, close, 0}
+}
+
+// betweenI implements Between(). Open and Body are set to nil once they
+// reach a final state. Opened holds the tokens consumed by Open and N
+// counts the tokens consumed afterwards.
+type betweenI struct {
+	Open        Iteratee
+	Opened      *tokenList
+	Body, Close Iteratee
+	N           int
+}
+
+func (it betweenI) Final() error {
+	if it.Open != nil {
+		if err := it.Open.Final(); err != nil {
+			return err
+		}
+	}
+	if it.Body != nil {
+		if err := it.Body.Final(); err != nil {
+			return err
+		}
+	}
+	if err := it.Close.Final(); err != nil {
+		return it.unclosed(err)
+	}
+	return nil
+}
+
+func (it betweenI) Next(token []byte) (Iteratee, bool, error) {
+	var (
+		next Iteratee
+		read bool
+		err  error
+	)
+	opened := it.Open == nil
+	switch {
+	case it.Open != nil:
+		next, read, err = it.Open.Next(token)
+		if read {
+			it.Opened = it.Opened.push(token)
+		}
+		it.Open = next
+	case it.Body != nil:
+		next, read, err = it.Body.Next(token)
+		it.Body = next
+	default:
+		next, read, err = it.Close.Next(token)
+		if err != nil {
+			return nil, false, it.unclosed(err)
+		}
+		if next == nil {
+			return nil, read, nil
+		}
+		it.Close = next
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if read && opened {
+		it.N++
+	}
+	return it, read, nil
+}
+
+func (it betweenI) unclosed(err error) error {
+	return UnclosedErr{string(bytes.Join(it.Opened.slice(), []byte(" "))), it.N + it.Opened.len(), err}
+}
+
+// UnclosedErr reports a missing closing delimiter. Since Iteratees do not
+// know the positions of tokens, Distance is its only position: the
+// opening delimiter starts Distance tokens before the failing token, or
+// before the end of input. A TokenErr wrapping it gives the index of the
+// failing token.
+type UnclosedErr struct {
+	Open     string // the opening delimiter, tokens separated by space.
+	Distance int    // the number of tokens since the start of Open.
+	Err      error  // the error of the closing delimiter.
+}
+
+func (e UnclosedErr) Error() string {
+	return fmt.Sprintf("unclosed %q opened %s before: %v", e.Open, plural(e.Distance, "token"), e.Err)
+}
+
+func (e UnclosedErr) Unwrap() error { return e.Err }
+
+// Balanced matches a group that starts with open and ends with the
+// matching close, e.g. "( a ( b ) )" for Balanced("(", ")", 0). Other
+// tokens are allowed anywhere inside the group. When maxDepth > 0, it
+// fails with ErrDepth on a group nested deeper than maxDepth. An
+// unclosed group is reported as an UnclosedErr locating the innermost
+// unclosed open.
+func Balanced(open, close string, maxDepth int) Iteratee {
+	return balancedI{open, close, maxDepth, 0, nil}
+}
+
+// balancedI implements Balanced(). N is the number of consumed tokens
+// and Opens holds the index of each unclosed open, outermost first.
+type balancedI struct {
+	Open, Close string
+	Max         int
+	N           int
+	Opens       []int
+}
+
+func (it balancedI) Final() error {
+	if len(it.Opens) == 0 {
+		return ErrExpectQ(it.Open)
+	}
+	return UnclosedErr{it.Open, it.N - it.Opens[len(it.Opens)-1], ErrExpectQ(it.Close)}
+}
+
+func (it balancedI) Next(token []byte) (Iteratee, bool, error) {
+	depth := len(it.Opens)
+	switch {
+	case string(token) == it.Open:
+		if it.Max > 0 && depth == it.Max {
+			return nil, false, ErrDepth(it.Max)
+		}
+		it.Opens = append(it.Opens[:depth:depth], it.N)
+	case depth == 0:
+		return nil, false, ErrExpectQ(it.Open)
+	case string(token) == it.Close:
+		if depth == 1 {
+			return nil, true, nil
+		}
+		it.Opens = it.Opens[:depth-1]
+	}
+	it.N++
+	return it, true, nil
+}
+
+// ErrDepth reports a group nested deeper than the limit.
+type ErrDepth int
+
+func (e ErrDepth) Error() string { return fmt.Sprintf("nested deeper than %d", int(e)) }
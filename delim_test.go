@@ -0,0 +1,65 @@
+package stream
+
+import "testing"
+
+func TestBetween(t *testing.T) {
+	list := Seq(Between(Match("("), Star(Match("x")), Match(")")), EOF)
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"( )", true},
+		{"( x x )", true},
+		{"x )", false},
+		{"( x", false},
+		{"( x ]", false},
+	} {
+		err := runWords(i.Input, list)
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+
+	angles := Between(Seq(Match("<"), Match("<")), Star(Match("x")), Seq(Match(">"), Match(">")))
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		Err   string
+	}{
+		{"( x x ]", list, `token "]" at offset 6 (token #3): unclosed "(" opened 3 tokens before: expect ")"`},
+		{"( x", list, `unclosed "(" opened 2 tokens before: expect ")"`},
+		{"< < x > ]", angles, `token "]" at offset 8 (token #4): unclosed "< <" opened 4 tokens before: expect ">"`},
+	} {
+		err := runWords(i.Input, i.It)
+		if err == nil || err.Error() != i.Err {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
+
+func TestBalanced(t *testing.T) {
+	for _, i := range []struct {
+		Input string
+		Max   int
+		Err   string
+	}{
+		{"( )", 0, ""},
+		{"( a ( b ( ) ) c )", 0, ""},
+		{"( a ( b ( ) ) c )", 3, ""},
+		{"( a ( b ( ) ) c )", 2, `token "(" at offset 8 (token #4): nested deeper than 2`},
+		{"a ( )", 0, `token "a" at offset 0 (token #0): expect "("`},
+		{"( ) )", 0, `token ")" at offset 4 (token #2): expect <eof>`},
+		{"( a ( b )", 0, `unclosed "(" opened 5 tokens before: expect ")"`},
+		{"( a ( b", 0, `unclosed "(" opened 2 tokens before: expect ")"`},
+		{"", 0, `expect "("`},
+	} {
+		err := runWords(i.Input, Seq(Balanced("(", ")", i.Max), EOF))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
@@ -10,48 +10,223 @@ import (
 type ScanEnumerator struct {
 	in   *bufio.Scanner
 	scan bool // true iff we must call scan before getting next token.
+	// offset is the byte offset of the current token (-1 if unknown)
+	// and index is its zero-based position in the token sequence.
+	offset, index int
+	// consumed is the number of bytes the split function has advanced
+	// past. Only tracked when we own the split function.
+	consumed int
+	// failed is true once the scanner has stopped with an error.
+	failed bool
 }
 
 func (e *ScanEnumerator) Step(it Iteratee) (Iteratee, error) {
-	if e.scan && !e.in.Scan() {
-		err := e.in.Err()
-		if err == nil {
-			err = it.Final()
+	if e.scan {
+		if !e.in.Scan() {
+			err := e.in.Err()
+			e.failed = err != nil
+			if err == nil {
+				err = it.Final()
+			}
+			return nil, err
 		}
-		return nil, err
+		e.index++
 	}
 	token := e.in.Bytes()
 	next, read, err := it.Next(token)
 	e.scan = read
-	return next, WrapTokenError(token, err)
+	return next, WrapTokenErrorAt(token, e.offset, e.index, err)
 }
 
+// NewScanEnumerator creates a ScanEnumerator reading from in. Since
+// the split function of in is not accessible, token offsets are not
+// reported in errors.
 func NewScanEnumerator(in *bufio.Scanner) *ScanEnumerator {
-	return &ScanEnumerator{in, true}
+	return &ScanEnumerator{in: in, scan: true, offset: -1, index: -1}
 }
 
-func NewScanEnumeratorWith(in io.Reader, split bufio.SplitFunc) *ScanEnumerator {
+// NewScanEnumeratorWith creates a ScanEnumerator reading from in and
+// splitting it with split. Its buffer may be configured with
+// BufferSize, Buffer and MaxTokenSize.
+func NewScanEnumeratorWith(in io.Reader, split bufio.SplitFunc, opts ...ScanOption) *ScanEnumerator {
+	return newScanEnumerator(in, split, newScanConfig(opts))
+}
+
+// Lines creates a ScanEnumerator over the lines read from in, without
+// their end-of-line markers (see bufio.ScanLines). A line longer than
+// the maximum token size (see MaxTokenSize) fails with bufio.ErrTooLong.
+func Lines(in io.Reader, opts ...ScanOption) *ScanEnumerator {
+	return newScanEnumerator(in, bufio.ScanLines, newScanConfig(opts))
+}
+
+// Words creates a ScanEnumerator over the space-separated words read
+// from in (see bufio.ScanWords).
+func Words(in io.Reader, opts ...ScanOption) *ScanEnumerator {
+	return newScanEnumerator(in, bufio.ScanWords, newScanConfig(opts))
+}
+
+func newScanEnumerator(in io.Reader, split bufio.SplitFunc, cfg scanConfig) *ScanEnumerator {
 	enum := NewScanEnumerator(bufio.NewScanner(in))
-	enum.in.Split(split)
+	if cfg.trailing != TrailingDrop {
+		split = enum.trailing(split, cfg.trailing)
+	}
+	enum.in.Split(enum.track(split))
+	if cfg.maxTokenSize > 0 || cfg.buf != nil || cfg.bufferSize > 0 {
+		buf, max := cfg.buf, cfg.maxTokenSize
+		if buf == nil && cfg.bufferSize > 0 {
+			buf = make([]byte, cfg.bufferSize)
+		}
+		if max <= 0 {
+			max = bufio.MaxScanTokenSize
+		}
+		enum.in.Buffer(buf, max)
+	}
 	return enum
 }
 
-// TokenErr wraps an error with the input token.
+// ScanOption configures a ScanEnumerator created by a constructor that
+// reads from an io.Reader.
+type ScanOption func(*scanConfig)
+
+// scanConfig holds the settings of ScanOptions. Zero values mean the
+// defaults.
+type scanConfig struct {
+	maxTokenSize, bufferSize int
+	buf                      []byte
+	trailing                 TrailingMode
+}
+
+func newScanConfig(opts []ScanOption) scanConfig {
+	var cfg scanConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// MaxTokenSize sets the maximum token size, which is
+// bufio.MaxScanTokenSize by default. It includes any delimiter that the
+// split function strips from the token, e.g. the end of a line.
+func MaxTokenSize(n int) ScanOption {
+	return func(cfg *scanConfig) { cfg.maxTokenSize = n }
+}
+
+// BufferSize sets the initial size of the buffer, which grows as needed
+// up to the maximum token size.
+func BufferSize(n int) ScanOption {
+	return func(cfg *scanConfig) { cfg.bufferSize = n }
+}
+
+// Buffer sets the initial buffer, e.g. to reuse one across
+// ScanEnumerators. It must not be used elsewhere while the
+// ScanEnumerator is in use. It takes precedence over BufferSize, and
+// the maximum token size is at least its capacity (see
+// bufio.Scanner.Buffer).
+func Buffer(buf []byte) ScanOption {
+	return func(cfg *scanConfig) { cfg.buf = buf }
+}
+
+// Trailing sets how data left at the end of input is treated when the
+// split function does not make a token of it.
+func Trailing(mode TrailingMode) ScanOption {
+	return func(cfg *scanConfig) { cfg.trailing = mode }
+}
+
+// TrailingMode tells how a ScanEnumerator treats data left at the end of
+// input, e.g. a truncated record.
+type TrailingMode int
+
+const (
+	TrailingDrop  TrailingMode = iota // drop the data silently, like bufio.Scanner (default).
+	TrailingError                     // fail with a TrailingErr.
+	TrailingToken                     // present the data as a last token.
+)
+
+// trailing wraps split to treat the data it leaves at the end of input
+// according to mode.
+func (e *ScanEnumerator) trailing(split bufio.SplitFunc, mode TrailingMode) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := split(data, atEOF)
+		if !atEOF || advance > 0 || token != nil || err != nil || len(data) == 0 {
+			return advance, token, err
+		}
+		if mode == TrailingToken {
+			return len(data), data, nil
+		}
+		return 0, nil, TrailingErr{e.consumed, len(data)}
+	}
+}
+
+// TrailingErr reports data left at the end of input that the split
+// function does not make a token of.
+type TrailingErr struct {
+	Offset int // byte offset of the data.
+	Size   int // size of the data in bytes.
+}
+
+func (e TrailingErr) Error() string {
+	return fmt.Sprintf("incomplete trailing token of %s at offset %d", plural(e.Size, "byte"), e.Offset)
+}
+
+// track wraps split to keep e.offset and e.consumed up to date.
+func (e *ScanEnumerator) track(split bufio.SplitFunc) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := split(data, atEOF)
+		if token != nil {
+			e.offset = e.consumed + tokenStart(data, token)
+		}
+		e.consumed += advance
+		return advance, token, err
+	}
+}
+
+// tokenStart returns the index of token in data when token is a slice
+// of data, or 0 otherwise (e.g. when token is a fresh slice).
+func tokenStart(data, token []byte) int {
+	if len(token) == 0 || cap(token) > cap(data) {
+		return 0
+	}
+	i := cap(data) - cap(token)
+	if i <= len(data) && &data[:cap(data)][i] == &token[0] {
+		return i
+	}
+	return 0
+}
+
+// TokenErr wraps an error with the input token and its position.
 type TokenErr struct {
-	Token string
-	Err   error
+	Token  string
+	Offset int // byte offset of the token in the input; -1 if unknown.
+	Index  int // zero-based index of the token; -1 if unknown.
+	Err    error
 }
 
 func (e TokenErr) Error() string {
-	return fmt.Sprintf("token %q: %v", e.Token, e.Err)
+	pos := ""
+	if e.Offset >= 0 {
+		pos += fmt.Sprintf(" at offset %d", e.Offset)
+	}
+	if e.Index >= 0 {
+		pos += fmt.Sprintf(" (token #%d)", e.Index)
+	}
+	return fmt.Sprintf("token %q%s: %v", e.Token, pos, e.Err)
 }
 
-// WrapTokenError creates an appropriate error when err is not nil.
+func (e TokenErr) Unwrap() error { return e.Err }
+
+// WrapTokenError creates an appropriate error when err is not nil. The
+// position of token is unknown; see WrapTokenErrorAt.
 func WrapTokenError(token []byte, err error) error {
+	return WrapTokenErrorAt(token, -1, -1, err)
+}
+
+// WrapTokenErrorAt is like WrapTokenError for a token at the given byte
+// offset and index, either of which may be -1 if unknown.
+func WrapTokenErrorAt(token []byte, offset, index int, err error) error {
 	if err == nil {
 		return nil
 	}
-	return TokenErr{string(token), err}
+	return TokenErr{string(token), offset, index, err}
 }
 
 // SplitState is a state in a stateful bufio.SplitFunc.
@@ -66,3 +241,30 @@ func StatefulSplitFunc(s SplitState) bufio.SplitFunc {
 		return
 	}
 }
+
+// SplitStateAt is like SplitState but is also given the offset of data
+// in the input, so that it can report where a lexical error is, e.g. as
+// a SplitErr.
+type SplitStateAt interface {
+	NextAt(offset int, data []byte, atEOF bool) (state SplitStateAt, advance int, token []byte, err error)
+}
+
+// StatefulSplitFuncAt creates a bufio.SplitFunc that starts from state s
+// at offset 0 of the input.
+func StatefulSplitFuncAt(s SplitStateAt) bufio.SplitFunc {
+	offset := 0
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		s, advance, token, err = s.NextAt(offset, data, atEOF)
+		offset += advance
+		return
+	}
+}
+
+// SplitErr is an error of a split function at a byte offset in the input.
+type SplitErr struct {
+	Offset int
+	Err    error
+}
+
+func (e SplitErr) Error() string { return fmt.Sprintf("offset %d: %v", e.Offset, e.Err) }
+func (e SplitErr) Unwrap() error { return e.Err }
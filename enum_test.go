@@ -2,6 +2,7 @@ package stream
 
 import (
 	"bufio"
+	"errors"
 	"reflect"
 	"strings"
 	"testing"
@@ -33,3 +34,152 @@ func TestStatefulSplitFunc(t *testing.T) {
 		t.Error("Tokens:\n", pretty.Compare(tokens, expectedTokens))
 	}
 }
+
+func TestTokenErrPosition(t *testing.T) {
+	for _, i := range []struct {
+		Input         string
+		It            Iteratee
+		Offset, Index int
+	}{
+		{"(ab  cd x)", Seq(Match("("), Match("ab"), Match("cd"), Match("y")), 8, 3},
+		// "cd" is declined twice before the error.
+		{"(ab  cd x)", Seq(Match("("), SkipAny("ab"), SkipAny("z"), Match("y")), 5, 2},
+		{"  \n(", Match(")"), 3, 0},
+	} {
+		err := Run(NewScanEnumeratorWith(strings.NewReader(i.Input), SplitSExpr), i.It)
+		te, ok := err.(TokenErr)
+		if !ok {
+			t.Errorf("input %q: expect TokenErr; got %#v", i.Input, err)
+			continue
+		}
+		if te.Offset != i.Offset || te.Index != i.Index {
+			t.Errorf("input %q: expect offset %d index %d; got %d %d", i.Input, i.Offset, i.Index, te.Offset, te.Index)
+		}
+	}
+
+	err := Run(NewScanEnumeratorWith(strings.NewReader("a b"), bufio.ScanWords), Seq(Match("a"), Match("c")))
+	if expected := `token "b" at offset 2 (token #1): expect "c"`; err == nil || err.Error() != expected {
+		t.Errorf("expect %q; got %v", expected, err)
+	}
+	err = Run(NewScanEnumerator(bufio.NewScanner(strings.NewReader("a"))), Match("c"))
+	if expected := `token "a" (token #0): expect "c"`; err == nil || err.Error() != expected {
+		t.Errorf("expect %q; got %v", expected, err)
+	}
+	if !errors.Is(err, ErrExpectQ("c")) {
+		t.Errorf("expect TokenErr to wrap ErrExpectQ; got %#v", err)
+	}
+	if expected := `token "a": boom`; WrapTokenError([]byte("a"), errors.New("boom")).Error() != expected {
+		t.Errorf("expect %q", expected)
+	}
+}
+
+func TestLinesWords(t *testing.T) {
+	text := "( x\r\nx\n\n)"
+	if err := Run(Words(strings.NewReader(text)), Seq(Match("("), Star(Match("x")), Match(")"), EOF)); err != nil {
+		t.Errorf("words: unexpected error %v", err)
+	}
+	if err := Run(Lines(strings.NewReader(text)), Seq(Match("( x"), Match("x"), Match(""), Match(")"), EOF)); err != nil {
+		t.Errorf("lines: unexpected error %v", err)
+	}
+	err := Run(Lines(strings.NewReader("abc\nabcd\n"), MaxTokenSize(4)), Star(Skip))
+	if err != bufio.ErrTooLong {
+		t.Errorf("expect bufio.ErrTooLong; got %v", err)
+	}
+	if err := Run(Lines(strings.NewReader("abc\nabc\n"), MaxTokenSize(4)), Star(Skip)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestScanOptions(t *testing.T) {
+	long := strings.Repeat("x", 100) + "\n"
+	for _, i := range []struct {
+		Name string
+		Opts []ScanOption
+		Err  error
+	}{
+		{"default", nil, nil},
+		{"small max", []ScanOption{MaxTokenSize(64)}, bufio.ErrTooLong},
+		{"small initial", []ScanOption{BufferSize(8)}, nil},
+		{"small initial and max", []ScanOption{BufferSize(8), MaxTokenSize(64)}, bufio.ErrTooLong},
+		{"large buffer", []ScanOption{Buffer(make([]byte, 128)), MaxTokenSize(64)}, nil},
+		{"small buffer", []ScanOption{Buffer(make([]byte, 8)), MaxTokenSize(101)}, nil},
+	} {
+		e := NewScanEnumeratorWith(strings.NewReader("a\n"+long), bufio.ScanLines, i.Opts...)
+		if err := Run(e, Seq(Match("a"), Skip, EOF)); err != i.Err {
+			t.Errorf("%s: expect error %v; got %v", i.Name, i.Err, err)
+		}
+	}
+}
+
+func TestTrailing(t *testing.T) {
+	// Records end with ";", which the last one lacks.
+	records := func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := strings.IndexByte(string(data), ';'); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	for _, i := range []struct {
+		Mode   TrailingMode
+		Tokens string
+		Err    string
+	}{
+		{TrailingDrop, "a b", ""},
+		{TrailingError, "a b", "incomplete trailing token of 2 bytes at offset 4"},
+		{TrailingToken, "a b cd", ""},
+	} {
+		var tokens []string
+		e := NewScanEnumeratorWith(strings.NewReader("a;b;cd"), records, Trailing(i.Mode))
+		err := Run(e, Seq(Capture(Star(Skip), appendTo(&tokens))))
+		if i.Err == "" && err != nil {
+			t.Errorf("mode %d: unexpected error %v", i.Mode, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("mode %d: expect error %q; got %v", i.Mode, i.Err, err)
+		}
+		if i.Err == "" && (len(tokens) != 1 || tokens[0] != i.Tokens) {
+			t.Errorf("mode %d: expect tokens %q; got %q", i.Mode, i.Tokens, tokens)
+		}
+	}
+
+	// Complete input is not affected.
+	if err := Run(NewScanEnumeratorWith(strings.NewReader("a;b;"), records, Trailing(TrailingError)), Seq(Match("a"), Match("b"), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// asciiOnly is a SplitStateAt for bytes that rejects any non-ASCII byte.
+type asciiOnly struct{}
+
+func (s asciiOnly) NextAt(offset int, data []byte, atEOF bool) (SplitStateAt, int, []byte, error) {
+	if len(data) == 0 {
+		return s, 0, nil, nil
+	}
+	if data[0] >= 0x80 {
+		return s, 0, nil, SplitErr{offset, errors.New("non-ASCII byte")}
+	}
+	return s, 1, data[:1], nil
+}
+
+func TestStatefulSplitFuncAt(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Split  SplitStateAt
+		Tokens string
+		Err    string
+	}{
+		{"abc", asciiOnly{}, "a|b|c", ""},
+		{"ab\xffc", asciiOnly{}, "a|b", "offset 2: non-ASCII byte"},
+		{`[1, "a", 01]`, JSONSplit{}, `[|1|,|"a"|,`, `offset 9: invalid JSON: invalid number "01"`},
+		{"\n  @", JSONSplit{}, "", "offset 3: invalid JSON: unexpected byte '@'"},
+	} {
+		tokens, err := splitAll(i.Input, StatefulSplitFuncAt(i.Split))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package stream
+
+import (
+	"bufio"
+	"io"
+)
+
+// Enumeratee is a stream transformer: given the downstream Iteratee, it
+// returns an Iteratee that consumes the upstream tokens and feeds the
+// tokens it produces from them to the downstream one, e.g. decoded,
+// filtered or re-framed. Together with an Enumerator, which produces
+// tokens, and an Iteratee, which consumes them, it completes the
+// iteratee pattern:
+//
+//	Run(e, Compose(Pipe(Resplit(bufio.ScanLines, 0), Filtering(nonEmpty)), it))
+//
+// The returned Iteratee finishes when the downstream one does, and the
+// downstream one is given the end of input with the upstream one.
+type Enumeratee func(it Iteratee) Iteratee
+
+// Compose returns the Iteratee feeding the output of t to it.
+func Compose(t Enumeratee, it Iteratee) Iteratee {
+	return t(it)
+}
+
+// Pipe stacks ts into one Enumeratee, in the order that the tokens pass
+// through them: the output of each is the input of the next one.
+func Pipe(ts ...Enumeratee) Enumeratee {
+	return func(it Iteratee) Iteratee {
+		for i := len(ts) - 1; i >= 0; i-- {
+			it = ts[i](it)
+		}
+		return it
+	}
+}
+
+// Mapping is the Enumeratee of MapTokens.
+func Mapping(f func(token []byte) []byte) Enumeratee {
+	return func(it Iteratee) Iteratee { return MapTokens(f, it) }
+}
+
+// Filtering is the Enumeratee of Filter.
+func Filtering(pred func(token []byte) bool) Enumeratee {
+	return func(it Iteratee) Iteratee { return Filter(pred, it) }
+}
+
+// Resplit is an Enumeratee that re-frames the input: it concatenates the
+// upstream tokens and splits the result again with split, so that a
+// downstream token may span several upstream ones, e.g. lines from the
+// chunks of a stream of messages. Like a bufio.Scanner, it fails with
+// bufio.ErrTooLong when a token needs more than max bytes of input
+// (bufio.MaxScanTokenSize if max <= 0), and with io.ErrNoProgress on too
+// many empty tokens in a row that do not advance the input. The data
+// that split leaves at the end of input is dropped.
+func Resplit(split bufio.SplitFunc, max int) Enumeratee {
+	if max <= 0 {
+		max = bufio.MaxScanTokenSize
+	}
+	return func(it Iteratee) Iteratee { return resplitI{Split: split, Max: max, A: it} }
+}
+
+// maxEmptyTokens is the number of empty tokens in a row without
+// advancing the input after which Resplit gives up, as bufio.Scanner.
+const maxEmptyTokens = 100
+
+// resplitI implements Resplit(). Buf.Data[Start:End] is the upstream
+// data that has not been split yet, and Empties counts the empty tokens
+// split from it in a row. Since a state may be run more than once, data
+// in Buf is never modified: a state only appends to Buf in place when
+// its data ends Buf.Data, and otherwise copies its data to a new Buf.
+type resplitI struct {
+	Split      bufio.SplitFunc
+	Max        int
+	Buf        *resplitBuf
+	Start, End int
+	Empties    int
+	A          Iteratee
+}
+
+// resplitBuf is the data shared by the states of a Resplit.
+type resplitBuf struct {
+	Data []byte
+}
+
+func (it resplitI) Final() error {
+	next, err := it.feed(true)
+	if err != nil || next == nil {
+		return err
+	}
+	return next.A.Final()
+}
+
+func (it resplitI) Next(token []byte) (Iteratee, bool, error) {
+	it.append(token)
+	next, err := it.feed(false)
+	if err != nil {
+		return nil, false, err
+	}
+	if next == nil {
+		return nil, true, nil
+	}
+	if next.End-next.Start > it.Max {
+		return nil, false, bufio.ErrTooLong
+	}
+	return *next, true, nil
+}
+
+// append adds token to the data of it.
+func (it *resplitI) append(token []byte) {
+	if it.Buf != nil && it.End == len(it.Buf.Data) && it.Start <= it.End/2 {
+		it.Buf.Data = append(it.Buf.Data, token...)
+		it.End = len(it.Buf.Data)
+		return
+	}
+	// Start a new Buf without the data that has been split already.
+	var data []byte
+	if it.Buf != nil {
+		data = it.Buf.Data[it.Start:it.End]
+	}
+	buf := make([]byte, 0, 2*(len(data)+len(token)))
+	buf = append(append(buf, data...), token...)
+	it.Buf, it.Start, it.End = &resplitBuf{buf}, 0, len(buf)
+}
+
+// feed splits the data of it and feeds the tokens to A. It returns the
+// remaining state, or nil when A has finished.
+func (it resplitI) feed(atEOF bool) (*resplitI, error) {
+	for {
+		var data []byte
+		if it.Buf != nil {
+			data = it.Buf.Data[it.Start:it.End]
+		}
+		if len(data) == 0 && !atEOF {
+			break
+		}
+		advance, token, err := it.Split(data, atEOF)
+		final := err == bufio.ErrFinalToken
+		if err != nil && !final {
+			return nil, err
+		}
+		if advance < 0 {
+			return nil, bufio.ErrNegativeAdvance
+		}
+		if advance > len(data) {
+			return nil, bufio.ErrAdvanceTooFar
+		}
+		it.Start += advance
+		if token != nil {
+			if advance > 0 {
+				it.Empties = 0
+			} else if it.Empties++; it.Empties > maxEmptyTokens {
+				return nil, io.ErrNoProgress
+			}
+			next, _, err := step(it.A, token)
+			if err != nil {
+				return nil, err
+			}
+			if next == nil {
+				return nil, nil
+			}
+			it.A = next
+		}
+		if final {
+			return nil, it.A.Final()
+		}
+		if advance == 0 && token == nil {
+			break
+		}
+	}
+	return &it, nil
+}
@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEnumeratee(t *testing.T) {
+	upper := Mapping(bytes.ToUpper)
+	nonEmpty := Filtering(func(token []byte) bool { return len(token) > 0 })
+	for _, i := range []struct {
+		Chunks []string
+		T      Enumeratee
+		Tokens string
+		Err    string
+	}{
+		{[]string{"a b", "c"}, upper, "A B|C", ""},
+		{[]string{"ab\nc", "d\n\ne", "f"}, Resplit(bufio.ScanLines, 0), "ab|cd||ef", ""},
+		{[]string{"ab\nc", "d\n\ne", "f"}, Pipe(Resplit(bufio.ScanLines, 0), nonEmpty, upper), "AB|CD|EF", ""},
+		{[]string{"a", "b c", "d"}, Resplit(bufio.ScanWords, 0), "ab|cd", ""},
+		{[]string{}, Resplit(bufio.ScanWords, 0), "", ""},
+	} {
+		var tokens []string
+		it := Compose(i.T, Seq(Capture(Star(Skip), func(all [][]byte) {
+			for _, t := range all {
+				tokens = append(tokens, string(t))
+			}
+		})))
+		err := Run(NewStringsEnumerator(i.Chunks), it)
+		if i.Err == "" && err != nil {
+			t.Errorf("chunks %q: unexpected error %v", i.Chunks, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("chunks %q: expect error %q; got %v", i.Chunks, i.Err, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("chunks %q: expect tokens %q; got %q", i.Chunks, i.Tokens, got)
+		}
+	}
+
+	// The downstream Iteratee finishes early, on the upstream token
+	// with the downstream one after its match, and fails.
+	it := Compose(Resplit(bufio.ScanWords, 0), Seq(Match("ab"), Match("c")))
+	if err := Run(NewStringsEnumerator([]string{"a", "b c ", "d ", "rest"}), Seq(it, Match("rest"), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	it = Compose(Resplit(bufio.ScanWords, 0), Seq(Match("ab"), Match("x")))
+	err := Run(NewStringsEnumerator([]string{"a", "b c "}), it)
+	if want := `token "b c " at offset 1 (token #1): expect "x"`; err == nil || err.Error() != want {
+		t.Errorf("expect error %q; got %v", want, err)
+	}
+
+	// Limits.
+	err = Run(NewStringsEnumerator([]string{"ab", "cd", "ef"}), Compose(Resplit(bufio.ScanWords, 4), Star(Skip)))
+	if !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("expect bufio.ErrTooLong; got %v", err)
+	}
+	stuck := func(data []byte, atEOF bool) (int, []byte, error) { return 0, data[:0], nil }
+	err = Run(NewStringsEnumerator([]string{"a"}), Compose(Resplit(stuck, 0), Star(Skip)))
+	if !errors.Is(err, io.ErrNoProgress) {
+		t.Errorf("expect io.ErrNoProgress; got %v", err)
+	}
+
+	// A state run twice keeps its own data.
+	var words []string
+	s, _, _ := Compose(Resplit(bufio.ScanWords, 0), Star(Capture(Skip, appendTo(&words)))).Next([]byte("a"))
+	for _, chunk := range []string{"b c", "x y"} {
+		next, _, err := s.Next([]byte(chunk))
+		if err == nil {
+			err = next.Final()
+		}
+		if err != nil {
+			t.Errorf("chunk %q: unexpected error %v", chunk, err)
+		}
+	}
+	if got := strings.Join(words, "|"); got != "ab|c|ax|y" {
+		t.Errorf("expect %q; got %q", "ab|c|ax|y", got)
+	}
+}
@@ -0,0 +1,101 @@
+package stream
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// FanOut reads e once and feeds every token to each of its, which run
+// concurrently in their own goroutines on their own copies of the
+// token. It returns the error of each Iteratee, in the order of its,
+// once all of them have finished. As with Run, an Iteratee that reaches
+// a final state ignores the rest of the input, and FanOut stops reading
+// soon after all of them have. An error from e itself is reported to the
+// Iteratees that are still running instead of calling their Final.
+func FanOut(e Enumerator, its ...Iteratee) []error {
+	errs := make([]error, len(its))
+	ws := make([]*fanWorker, len(its))
+	f := &fanOut{ws: ws}
+	f.active.Store(int64(len(its)))
+	var wg sync.WaitGroup
+	for i, it := range its {
+		ws[i] = &fanWorker{it: it, tokens: make(chan []byte, fanBuffer)}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = ws[i].run(f)
+		}(i)
+	}
+	var err error
+	if len(its) > 0 {
+		err = Run(e, fanI{f})
+	}
+	f.err = err
+	for _, w := range ws {
+		close(w.tokens)
+	}
+	wg.Wait()
+	return errs
+}
+
+// fanBuffer is the number of tokens that may be queued for each
+// Iteratee of FanOut.
+const fanBuffer = 64
+
+// fanOut is the state shared by the workers of FanOut(). err is the
+// error of the Enumerator, which is only read once the token channels
+// are closed.
+type fanOut struct {
+	ws     []*fanWorker
+	active atomic.Int64
+	err    error
+}
+
+// fanWorker runs one Iteratee of FanOut() on the tokens it receives.
+type fanWorker struct {
+	it     Iteratee
+	tokens chan []byte
+	index  int
+}
+
+func (w *fanWorker) run(f *fanOut) error {
+	var err error
+	for token := range w.tokens {
+		if w.it == nil || err != nil {
+			continue
+		}
+		var e error
+		w.it, _, e = step(w.it, token)
+		if e != nil {
+			err = WrapTokenErrorAt(token, -1, w.index, e)
+		}
+		w.index++
+		if w.it == nil || err != nil {
+			f.active.Add(-1)
+		}
+	}
+	if w.it == nil || err != nil {
+		return err
+	}
+	if f.err != nil {
+		return f.err
+	}
+	return w.it.Final()
+}
+
+// fanI sends each token to the workers of FanOut(). It finishes when no
+// worker is running any more.
+type fanI struct {
+	F *fanOut
+}
+
+func (it fanI) Final() error { return nil }
+func (it fanI) Next(token []byte) (Iteratee, bool, error) {
+	if it.F.active.Load() == 0 {
+		return nil, false, nil
+	}
+	for _, w := range it.F.ws {
+		w.tokens <- append([]byte(nil), token...)
+	}
+	return it, true, nil
+}
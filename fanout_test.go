@@ -0,0 +1,42 @@
+package stream
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFanOut(t *testing.T) {
+	var all, first []string
+	errs := FanOut(Words(strings.NewReader("a b a c")),
+		Seq(Capture(Star(Skip), appendTo(&all))),
+		Seq(Capture(Match("a"), appendTo(&first))),
+		Seq(Star(Or(Match("a"), Match("b"))), EOF),
+		Star(Skip))
+	if errs[0] != nil || len(all) != 1 || all[0] != "a b a c" {
+		t.Errorf("expect all tokens; got %q, %v", all, errs[0])
+	}
+	if errs[1] != nil || len(first) != 1 || first[0] != "a" {
+		t.Errorf("expect first token; got %q, %v", first, errs[1])
+	}
+	if err, ok := errs[2].(TokenErr); !ok || err.Token != "c" || err.Index != 3 {
+		t.Errorf("expect error on token %q; got %v", "c", errs[2])
+	}
+	if errs[3] != nil {
+		t.Errorf("unexpected error %v", errs[3])
+	}
+
+	// An input error goes to the ones still running.
+	boom := errors.New("boom")
+	in := io.MultiReader(strings.NewReader("a b "), iotest.ErrReader(boom))
+	errs = FanOut(Words(in), Match("a"), Star(Skip))
+	if errs[0] != nil || !errors.Is(errs[1], boom) {
+		t.Errorf("expect nil and %v; got %v", boom, errs)
+	}
+
+	if errs := FanOut(Words(strings.NewReader("a"))); len(errs) != 0 {
+		t.Errorf("expect no errors; got %v", errs)
+	}
+}
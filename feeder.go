@@ -0,0 +1,56 @@
+package stream
+
+// Feeder drives an Iteratee with tokens pushed to it, for event-driven
+// code that receives tokens as they arrive, e.g. from callbacks.
+type Feeder struct {
+	it    Iteratee // nil once it reaches a final state.
+	err   error
+	index int
+}
+
+// NewFeeder creates a Feeder driving it.
+func NewFeeder(it Iteratee) *Feeder {
+	return &Feeder{it: it}
+}
+
+// Push feeds token to the Iteratee until it is consumed. The token may
+// be reused once Push returns. Once an error occurs, Push returns it
+// again without doing anything. A token that the Iteratee does not take
+// after reaching its final state is an error like with EOF.
+func (f *Feeder) Push(token []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+	for {
+		if f.it == nil {
+			f.err = WrapTokenErrorAt(token, -1, f.index, ErrExpect("<eof>"))
+			return f.err
+		}
+		next, read, err := f.it.Next(token)
+		if err != nil {
+			f.err = WrapTokenErrorAt(token, -1, f.index, err)
+			return f.err
+		}
+		f.it = next
+		if read {
+			f.index++
+			return nil
+		}
+	}
+}
+
+// Close marks the end of input and returns the first error, if any.
+func (f *Feeder) Close() error {
+	if f.err == nil && f.it != nil {
+		f.err = f.it.Final()
+		f.it = nil
+	}
+	return f.err
+}
+
+// Done reports whether the Iteratee has reached a final state, after
+// which no more token should be pushed. Note that many Iteratees only
+// reach it on the token after their match.
+func (f *Feeder) Done() bool {
+	return f.it == nil
+}
@@ -0,0 +1,41 @@
+package stream
+
+import "testing"
+
+func TestFeeder(t *testing.T) {
+	list := Seq(Match("("), Star(Match("x")), Match(")"))
+	for _, i := range []struct {
+		Input    []string
+		Done     bool
+		Err      string
+		CloseErr string
+	}{
+		// Seq only finishes on the token after its last member.
+		{[]string{"(", "x", "x", ")"}, false, "", ""},
+		{[]string{"(", "x"}, false, "", `expect ")"`},
+		{[]string{"(", "y", ")"}, false, `token "y" (token #1): expect ")"`, `token "y" (token #1): expect ")"`},
+		{[]string{"(", ")", "x"}, true, `token "x" (token #2): expect <eof>`, `token "x" (token #2): expect <eof>`},
+	} {
+		f := NewFeeder(list)
+		var err error
+		for _, s := range i.Input {
+			if err = f.Push([]byte(s)); err != nil {
+				break
+			}
+		}
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if f.Done() != i.Done {
+			t.Errorf("input %q: expect done %v", i.Input, i.Done)
+		}
+		err = f.Close()
+		if i.CloseErr == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v on close", i.Input, err)
+		} else if i.CloseErr != "" && (err == nil || err.Error() != i.CloseErr) {
+			t.Errorf("input %q: expect error %q on close; got %v", i.Input, i.CloseErr, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// FixedWidthSplit is a SplitState for rows of fixed-width fields, one
+// row per line: each field is a token of its raw bytes, padding
+// included, and each row ends with a RecordSep token. A row shorter than
+// the total width fails with ErrShortRow, and a longer one with
+// ErrRowWidth. Widths must be positive.
+type FixedWidthSplit struct {
+	Widths []int
+}
+
+func (s FixedWidthSplit) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return s, 0, nil, nil
+	}
+	return fixedField{s, 0}.Next(data, atEOF)
+}
+
+// fixedField is the state of FixedWidthSplit in a row, where Field is the
+// index of the next field.
+type fixedField struct {
+	S     FixedWidthSplit
+	Field int
+}
+
+func (s fixedField) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	line, eol := data, len(data)
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line, eol = data[:i], i+1
+	} else if !atEOF {
+		return s, 0, nil, nil
+	}
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	widths := s.S.Widths
+	if s.Field == 0 && len(line) < s.S.total() {
+		// Missing fields would need a run of empty tokens that consume
+		// nothing, which bufio.Scanner rejects past 100 of them.
+		return s, 0, nil, ErrShortRow(s.S.total())
+	}
+	if s.Field < len(widths) {
+		w := widths[s.Field]
+		s.Field++
+		return s, w, line[:w:w], nil
+	}
+	if len(line) > 0 {
+		return s, 0, nil, ErrRowWidth(s.S.total())
+	}
+	return s.S, eol, []byte(RecordSep), nil
+}
+
+// total returns the width of a row.
+func (s FixedWidthSplit) total() int {
+	total := 0
+	for _, w := range s.Widths {
+		total += w
+	}
+	return total
+}
+
+// NewFixedWidthEnumerator creates a ScanEnumerator over the rows of
+// fixed-width fields read from in (see FixedWidthSplit).
+func NewFixedWidthEnumerator(in io.Reader, widths ...int) *ScanEnumerator {
+	return NewScanEnumeratorWith(in, StatefulSplitFunc(FixedWidthSplit{widths}))
+}
+
+// ErrShortRow reports a row shorter than the total width of its fields.
+type ErrShortRow int
+
+func (e ErrShortRow) Error() string {
+	return fmt.Sprintf("row shorter than %s", plural(int(e), "byte"))
+}
+
+// ErrRowWidth reports a row longer than the total width of its fields.
+type ErrRowWidth int
+
+func (e ErrRowWidth) Error() string { return fmt.Sprintf("row longer than %s", plural(int(e), "byte")) }
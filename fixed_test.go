@@ -0,0 +1,54 @@
+package stream
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFixedWidthEnumerator(t *testing.T) {
+	var tokens []string
+	all := Star(Capture(Skip, appendTo(&tokens)))
+	for _, i := range []struct {
+		Input  string
+		Tokens []string
+		Err    string
+	}{
+		{"AB 0012\r\nCD 3456\n", []string{"AB ", "0012", RecordSep, "CD ", "3456", RecordSep}, ""},
+		{"AB 0012\nCD", []string{"AB ", "0012", RecordSep}, "row shorter than 7 bytes"},
+		{"\n", nil, "row shorter than 7 bytes"},
+		{"", nil, ""},
+		{"AB 00123\n", []string{"AB ", "0012"}, "row longer than 7 bytes"},
+	} {
+		tokens = nil
+		err := Run(NewFixedWidthEnumerator(strings.NewReader(i.Input), 3, 4), all)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if !reflect.DeepEqual(tokens, i.Tokens) {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, tokens)
+		}
+	}
+
+	// Fields validated by a grammar.
+	row := Seq(MatchOneOf("AB ", "CD "), Int(), EndOfRecord)
+	if err := Run(NewFixedWidthEnumerator(strings.NewReader("AB 0012\nCD 0x12\n"), 3, 4), Seq(Star(row), EOF)); err == nil || err.Error() != `token "0x12" at offset 11 (token #4): expect integer` {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// A wide layout, whose short rows fail rather than emit a long run of
+	// empty fields.
+	widths := make([]int, 200)
+	for i := range widths {
+		widths[i] = 1
+	}
+	wide := strings.Repeat("x", len(widths))
+	if err := Run(NewFixedWidthEnumerator(strings.NewReader(wide+"\n"+wide+"\n"), widths...), Seq(Star(Seq(Star(Match("x")), EndOfRecord)), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := Run(NewFixedWidthEnumerator(strings.NewReader(wide+"\nx\n"), widths...), Star(Skip)); err == nil || err.Error() != "row shorter than 200 bytes" {
+		t.Errorf("expect error %q; got %v", "row shorter than 200 bytes", err)
+	}
+}
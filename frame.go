@@ -0,0 +1,185 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// SplitVarint returns a bufio.SplitFunc for frames prefixed by their
+// length as an unsigned varint, as in protobuf delimited streams. Each
+// token is the payload of a frame. A frame longer than max bytes fails
+// with ErrFrameSize, and a frame cut short by the end of input with
+// io.ErrUnexpectedEOF.
+func SplitVarint(max int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		size, n := binary.Uvarint(data)
+		switch {
+		case n < 0:
+			return 0, nil, ErrVarint
+		case n == 0 && len(data) >= binary.MaxVarintLen64:
+			return 0, nil, ErrVarint
+		case n == 0 && atEOF:
+			return 0, nil, io.ErrUnexpectedEOF
+		case n == 0:
+			return 0, nil, nil
+		case size > uint64(max):
+			return 0, nil, ErrFrameSize(max)
+		}
+		end := n + int(size)
+		if end > len(data) {
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+		return end, data[n:end], nil
+	}
+}
+
+// NewVarintEnumerator creates a ScanEnumerator over the payloads of the
+// varint-length-delimited frames read from in (see SplitVarint).
+func NewVarintEnumerator(in io.Reader, max int) *ScanEnumerator {
+	return newScanEnumerator(in, SplitVarint(max), scanConfig{maxTokenSize: max + binary.MaxVarintLen64})
+}
+
+// ErrVarint reports an invalid varint.
+var ErrVarint = errors.New("invalid varint length")
+
+// ErrFrameSize reports a frame longer than the limit.
+type ErrFrameSize int
+
+func (e ErrFrameSize) Error() string {
+	return fmt.Sprintf("frame larger than %s", plural(int(e), "byte"))
+}
+
+// SplitLengthPrefixed returns a bufio.SplitFunc for frames prefixed by
+// their length as an unsigned integer of width bytes (1, 2, 4 or 8) in
+// the given byte order. Each token is the payload of a frame. If
+// inclusive is true, the length counts the prefix itself, and a length
+// smaller than width fails with ErrPrefixLength. A payload longer than
+// max bytes fails with ErrFrameSize, and a frame cut short by the end of
+// input with io.ErrUnexpectedEOF. It panics on any other width.
+func SplitLengthPrefixed(width int, order binary.ByteOrder, inclusive bool, max int) bufio.SplitFunc {
+	var read func([]byte) uint64
+	switch width {
+	case 1:
+		read = func(b []byte) uint64 { return uint64(b[0]) }
+	case 2:
+		read = func(b []byte) uint64 { return uint64(order.Uint16(b)) }
+	case 4:
+		read = func(b []byte) uint64 { return uint64(order.Uint32(b)) }
+	case 8:
+		read = order.Uint64
+	default:
+		panic(fmt.Sprintf("stream: invalid length prefix width %d", width))
+	}
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		if len(data) < width {
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+		size := read(data)
+		if inclusive {
+			if size < uint64(width) {
+				return 0, nil, ErrPrefixLength
+			}
+			size -= uint64(width)
+		}
+		if size > uint64(max) {
+			return 0, nil, ErrFrameSize(max)
+		}
+		end := width + int(size)
+		if end > len(data) {
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+		return end, data[width:end], nil
+	}
+}
+
+// NewLengthPrefixedEnumerator creates a ScanEnumerator over the payloads
+// of the length-prefixed frames read from in (see SplitLengthPrefixed).
+func NewLengthPrefixedEnumerator(in io.Reader, width int, order binary.ByteOrder, inclusive bool, max int) *ScanEnumerator {
+	return newScanEnumerator(in, SplitLengthPrefixed(width, order, inclusive, max), scanConfig{maxTokenSize: max + width})
+}
+
+// ErrPrefixLength reports a length prefix that counts itself but is
+// smaller than its own width.
+var ErrPrefixLength = errors.New("length prefix smaller than itself")
+
+// NetstringSplit is a SplitState for netstrings ("5:hello,"): each
+// token is the payload of a netstring. The length must be decimal
+// digits without leading zeros, and a payload longer than Max bytes
+// fails with ErrFrameSize. Malformed input fails with ErrNetstring and
+// a netstring cut short by the end of input with io.ErrUnexpectedEOF.
+type NetstringSplit struct {
+	Max int
+}
+
+func (s NetstringSplit) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	if len(data) == 0 {
+		return s, 0, nil, nil
+	}
+	size, colon := 0, -1
+	for i, b := range data {
+		if b == ':' {
+			colon = i
+			break
+		}
+		switch {
+		case b < '0' || b > '9':
+			return s, 0, nil, ErrNetstring("length must be decimal digits")
+		case i == 1 && data[0] == '0':
+			return s, 0, nil, ErrNetstring("length has leading zeros")
+		}
+		size = size*10 + int(b-'0')
+		if size > s.Max {
+			return s, 0, nil, ErrFrameSize(s.Max)
+		}
+	}
+	switch {
+	case colon == 0:
+		return s, 0, nil, ErrNetstring("missing length")
+	case colon < 0 && atEOF:
+		return s, 0, nil, io.ErrUnexpectedEOF
+	case colon < 0:
+		return s, 0, nil, nil
+	}
+	end := colon + 1 + size
+	if end >= len(data) {
+		if atEOF {
+			return s, 0, nil, io.ErrUnexpectedEOF
+		}
+		return s, 0, nil, nil
+	}
+	if data[end] != ',' {
+		return s, 0, nil, ErrNetstring("missing trailing ','")
+	}
+	return s, end + 1, data[colon+1 : end], nil
+}
+
+// NewNetstringEnumerator creates a ScanEnumerator over the payloads of
+// the netstrings read from in (see NetstringSplit).
+func NewNetstringEnumerator(in io.Reader, max int) *ScanEnumerator {
+	// Room for the length, the colon and the comma.
+	overhead := len(fmt.Sprint(max)) + 2
+	return newScanEnumerator(in, StatefulSplitFunc(NetstringSplit{max}), scanConfig{maxTokenSize: max + overhead})
+}
+
+// ErrNetstring reports a malformed netstring.
+type ErrNetstring string
+
+func (e ErrNetstring) Error() string { return "invalid netstring: " + string(e) }
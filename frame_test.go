@@ -0,0 +1,117 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// varintFrames encodes payloads as varint-length-delimited frames.
+func varintFrames(payloads ...string) string {
+	var buf bytes.Buffer
+	for _, p := range payloads {
+		buf.Write(binary.AppendUvarint(nil, uint64(len(p))))
+		buf.WriteString(p)
+	}
+	return buf.String()
+}
+
+func TestVarintEnumerator(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	for _, i := range []struct {
+		Input  string
+		Max    int
+		Frames []string
+		Err    error
+	}{
+		{varintFrames("ab", "", long), 300, []string{"ab", "", long}, nil},
+		{varintFrames("ab", long), 299, []string{"ab"}, ErrFrameSize(299)},
+		{varintFrames("ab", "cd")[:5], 10, []string{"ab"}, io.ErrUnexpectedEOF},
+		{varintFrames("ab", long)[:4], 1000, []string{"ab"}, io.ErrUnexpectedEOF},
+		{"\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01", 10, nil, ErrVarint},
+		{"", 10, nil, nil},
+	} {
+		var frames []string
+		err := Run(NewVarintEnumerator(strings.NewReader(i.Input), i.Max), Star(Capture(Skip, appendTo(&frames))))
+		if err != i.Err {
+			t.Errorf("input %q: expect error %v; got %v", i.Input, i.Err, err)
+		}
+		if !reflect.DeepEqual(frames, i.Frames) {
+			t.Errorf("input %q: expect frames %q; got %q", i.Input, i.Frames, frames)
+		}
+	}
+}
+
+func TestNetstringEnumerator(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Max    int
+		Frames []string
+		Err    error
+	}{
+		{"5:hello,0:,3:a,b,", 5, []string{"hello", "", "a,b"}, nil},
+		{"", 5, nil, nil},
+		{"5:hello,6:hello!,", 5, []string{"hello"}, ErrFrameSize(5)},
+		{"5:hello,123456:", 1000, []string{"hello"}, ErrFrameSize(1000)},
+		{"5:hello;", 5, nil, ErrNetstring("missing trailing ','")},
+		{"05:hello,", 5, nil, ErrNetstring("length has leading zeros")},
+		{"5x:hello,", 5, nil, ErrNetstring("length must be decimal digits")},
+		{":,", 5, nil, ErrNetstring("missing length")},
+		{"2:ab,5:hel", 5, []string{"ab"}, io.ErrUnexpectedEOF},
+		{"2:ab,5:hello", 5, []string{"ab"}, io.ErrUnexpectedEOF},
+		{"2:ab,5", 5, []string{"ab"}, io.ErrUnexpectedEOF},
+	} {
+		var frames []string
+		err := Run(NewNetstringEnumerator(strings.NewReader(i.Input), i.Max), Star(Capture(Skip, appendTo(&frames))))
+		if err != i.Err {
+			t.Errorf("input %q: expect error %v; got %v", i.Input, i.Err, err)
+		}
+		if !reflect.DeepEqual(frames, i.Frames) {
+			t.Errorf("input %q: expect frames %q; got %q", i.Input, i.Frames, frames)
+		}
+	}
+}
+
+func TestLengthPrefixedEnumerator(t *testing.T) {
+	for _, i := range []struct {
+		Input     string
+		Width     int
+		Order     binary.ByteOrder
+		Inclusive bool
+		Max       int
+		Frames    []string
+		Err       error
+	}{
+		{"\x02ab\x00\x01c", 1, binary.BigEndian, false, 10, []string{"ab", "", "c"}, nil},
+		{"\x00\x02ab\x00\x00", 2, binary.BigEndian, false, 10, []string{"ab", ""}, nil},
+		{"\x02\x00ab", 2, binary.LittleEndian, false, 10, []string{"ab"}, nil},
+		{"\x00\x00\x00\x06ab\x00\x00\x00\x04", 4, binary.BigEndian, true, 10, []string{"ab", ""}, nil},
+		{"\x05\x00\x00\x00\x00\x00\x00\x00abcde", 8, binary.LittleEndian, false, 10, []string{"abcde"}, nil},
+		{"\x00\x01", 2, binary.BigEndian, true, 10, nil, ErrPrefixLength},
+		{"\x02ab\x03a", 1, binary.BigEndian, false, 2, []string{"ab"}, ErrFrameSize(2)},
+		{"\x02ab\x02a", 1, binary.BigEndian, false, 10, []string{"ab"}, io.ErrUnexpectedEOF},
+		{"\x00\x02a", 2, binary.BigEndian, false, 10, nil, io.ErrUnexpectedEOF},
+		{"\x00", 2, binary.BigEndian, false, 10, nil, io.ErrUnexpectedEOF},
+		{"", 4, binary.BigEndian, false, 10, nil, nil},
+	} {
+		var frames []string
+		e := NewLengthPrefixedEnumerator(strings.NewReader(i.Input), i.Width, i.Order, i.Inclusive, i.Max)
+		err := Run(e, Star(Capture(Skip, appendTo(&frames))))
+		if err != i.Err {
+			t.Errorf("input %q: expect error %v; got %v", i.Input, i.Err, err)
+		}
+		if !reflect.DeepEqual(frames, i.Frames) {
+			t.Errorf("input %q: expect frames %q; got %q", i.Input, i.Frames, frames)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expect a panic on width 3")
+		}
+	}()
+	SplitLengthPrefixed(3, binary.BigEndian, false, 10)
+}
@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+)
+
+// FileSep starts the token that an Enumerator over several files puts
+// before the tokens of each file when asked to, e.g. NewFSEnumerator
+// with boundaries. The rest of the token is the name of the file. It is
+// the ASCII file separator.
+const FileSep = "\x1c"
+
+// FileStart matches a FileSep token and calls fn, if not nil, with the
+// name of the file.
+func FileStart(fn func(name string)) Iteratee {
+	return CutPrefix(FileSep, func(rest []byte) {
+		if fn != nil {
+			fn(string(rest))
+		}
+	})
+}
+
+// FSEnumerator is an Enumerator over files as if they were a single
+// input. The open file is closed when the run ends, with or without an
+// error, or by Close.
+type FSEnumerator struct {
+	concat concatEnumerator
+	fsys   fs.FS
+	names  []string
+	split  bufio.SplitFunc
+	cfg    scanConfig
+	// boundaries tells whether to put a FileSep token before each file.
+	boundaries bool
+	cur        fs.File
+}
+
+// NewFSEnumerator creates an FSEnumerator over the files in fsys matching
+// pattern (see fs.Glob), in lexical order. Each file is split by split,
+// so a token never spans files, and is only opened once the previous one
+// has been read. When boundaries is true, a FileSep token with the name
+// of each file comes before its tokens. Positions in errors are those
+// within the file of the token, and errors from a file are reported as a
+// FileErr. It fails only when pattern is malformed.
+func NewFSEnumerator(fsys fs.FS, pattern string, split bufio.SplitFunc, boundaries bool, opts ...ScanOption) (*FSEnumerator, error) {
+	names, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+	e := &FSEnumerator{fsys: fsys, names: names, split: split, cfg: newScanConfig(opts), boundaries: boundaries}
+	e.concat.more = e.more
+	return e, nil
+}
+
+func (e *FSEnumerator) Step(it Iteratee) (Iteratee, error) {
+	next, err := e.concat.Step(it)
+	if err != nil || next == nil {
+		e.Close()
+	}
+	return next, err
+}
+
+// Close closes the open file, if any. It may be called more than once.
+func (e *FSEnumerator) Close() error {
+	if e.cur == nil {
+		return nil
+	}
+	err := e.cur.Close()
+	e.cur = nil
+	return err
+}
+
+// more opens the next file for e.concat.
+func (e *FSEnumerator) more() (Enumerator, error) {
+	e.Close()
+	if len(e.names) == 0 {
+		return nil, io.EOF
+	}
+	name := e.names[0]
+	e.names = e.names[1:]
+	f, err := e.fsys.Open(name)
+	if err != nil {
+		return nil, FileErr{name, err}
+	}
+	e.cur = f
+	fe := fileEnumerator{name, newScanEnumerator(f, e.split, e.cfg)}
+	if !e.boundaries {
+		return fe, nil
+	}
+	return Concat(NewStringsEnumerator([]string{FileSep + name}), fe), nil
+}
+
+// fileEnumerator wraps the errors of E in a FileErr.
+type fileEnumerator struct {
+	Name string
+	E    Enumerator
+}
+
+func (e fileEnumerator) Step(it Iteratee) (Iteratee, error) {
+	next, err := e.E.Step(it)
+	if err != nil {
+		err = FileErr{e.Name, err}
+	}
+	return next, err
+}
+
+// FileErr reports an error in a file.
+type FileErr struct {
+	Name string
+	Err  error
+}
+
+func (e FileErr) Error() string { return e.Name + ": " + e.Err.Error() }
+func (e FileErr) Unwrap() error { return e.Err }
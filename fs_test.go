@@ -0,0 +1,66 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSEnumerator(t *testing.T) {
+	fsys := fstest.MapFS{
+		"logs/b.log": {Data: []byte("b1\nb2\n")},
+		"logs/a.log": {Data: []byte("a1\n")},
+		"logs/c.txt": {Data: []byte("c1\n")},
+		"logs/e.log": {Data: []byte("")},
+	}
+	var tokens []string
+	collect := OnToken(Star(Skip), func(token []byte) { tokens = append(tokens, string(token)) })
+
+	e, err := NewFSEnumerator(fsys, "logs/*.log", bufio.ScanLines, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(e, collect); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if got := strings.Join(tokens, " "); got != "a1 b1 b2" {
+		t.Errorf("expect %q; got %q", "a1 b1 b2", got)
+	}
+
+	tokens = nil
+	var files []string
+	e, _ = NewFSEnumerator(fsys, "logs/*.log", bufio.ScanLines, true)
+	line := OnToken(MatchFunc(func(token []byte) bool { return !strings.HasPrefix(string(token), FileSep) }, "line"),
+		func(token []byte) { tokens = append(tokens, string(token)) })
+	file := Seq(FileStart(func(name string) { files = append(files, name) }), Star(line))
+	if err := Run(e, Seq(Star(file), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if got := strings.Join(files, " "); got != "logs/a.log logs/b.log logs/e.log" {
+		t.Errorf("expect all log files; got %q", got)
+	}
+	if got := strings.Join(tokens, " "); got != "a1 b1 b2" {
+		t.Errorf("expect %q; got %q", "a1 b1 b2", got)
+	}
+
+	// Errors name the file.
+	e, _ = NewFSEnumerator(fsys, "logs/*.log", bufio.ScanLines, false)
+	err = Run(e, Seq(Star(Match("a1")), EOF))
+	var fe FileErr
+	if !errors.As(err, &fe) || fe.Name != "logs/b.log" {
+		t.Errorf("expect error in %q; got %v", "logs/b.log", err)
+	}
+
+	// The open file is closed when the run ends early.
+	e, _ = NewFSEnumerator(fsys, "logs/*.log", bufio.ScanLines, false)
+	if err := Run(e, Match("a1")); err != nil || e.cur != nil {
+		t.Errorf("expect the file closed; got %v", err)
+	}
+
+	if _, err := NewFSEnumerator(fsys, "[", bufio.ScanLines, false); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("expect bad pattern; got %v", err)
+	}
+}
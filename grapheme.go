@@ -0,0 +1,159 @@
+package stream
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// ScanGraphemes is a bufio.SplitFunc whose tokens are the extended
+// grapheme clusters of UTF-8 text, i.e. user-perceived characters such
+// as "é", "\r\n", a Hangul syllable made of jamo, a flag of two
+// regional indicators or an emoji joined by ZWJ. It follows the rules of
+// Unicode Standard Annex #29, with the character properties derived
+// from the tables of package unicode: marks extend a cluster, and
+// pictographs are those in the main emoji blocks. Prepended characters
+// are not handled. An invalid byte is a token of its own.
+func ScanGraphemes(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+	if !atEOF && !utf8.FullRune(data) {
+		return 0, nil, nil
+	}
+	r, size := utf8.DecodeRune(data)
+	prev := graphemeClassOf(r)
+	if r == utf8.RuneError && size == 1 {
+		return 1, data[:1], nil
+	}
+	// pict is true after an Extended_Pictographic followed by any
+	// Extend, and ri counts the regional indicators in a row.
+	pict := prev == gcPict
+	ri := 0
+	if prev == gcRI {
+		ri = 1
+	}
+	i := size
+	for i < len(data) {
+		if !atEOF && !utf8.FullRune(data[i:]) {
+			return 0, nil, nil
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i, data[:i], nil
+		}
+		next := graphemeClassOf(r)
+		if graphemeBreak(prev, next, pict, ri) {
+			return i, data[:i], nil
+		}
+		switch {
+		case next == gcPict:
+			pict = true
+		case next != gcExtend && next != gcZWJ:
+			pict = false
+		}
+		if next == gcRI {
+			ri++
+		} else {
+			ri = 0
+		}
+		prev = next
+		i += size
+	}
+	if !atEOF {
+		// The next rune may extend the cluster.
+		return 0, nil, nil
+	}
+	return len(data), data, nil
+}
+
+// Grapheme cluster break classes.
+const (
+	gcOther = iota
+	gcCR
+	gcLF
+	gcControl
+	gcExtend
+	gcZWJ
+	gcSpacingMark
+	gcRI
+	gcL
+	gcV
+	gcT
+	gcLV
+	gcLVT
+	gcPict
+)
+
+// graphemeBreak tells whether there is a cluster boundary between a rune
+// of class prev and one of class next.
+func graphemeBreak(prev, next int, pict bool, ri int) bool {
+	switch {
+	case prev == gcCR && next == gcLF:
+		return false
+	case prev == gcCR, prev == gcLF, prev == gcControl, next == gcCR, next == gcLF, next == gcControl:
+		return true
+	case prev == gcL && (next == gcL || next == gcV || next == gcLV || next == gcLVT):
+		return false
+	case (prev == gcLV || prev == gcV) && (next == gcV || next == gcT):
+		return false
+	case (prev == gcLVT || prev == gcT) && next == gcT:
+		return false
+	case next == gcExtend, next == gcZWJ, next == gcSpacingMark:
+		return false
+	case prev == gcZWJ && next == gcPict && pict:
+		return false
+	case prev == gcRI && next == gcRI:
+		return ri%2 == 0
+	}
+	return true
+}
+
+// graphemeClassOf returns the grapheme cluster break class of r.
+func graphemeClassOf(r rune) int {
+	switch {
+	case r == '\r':
+		return gcCR
+	case r == '\n':
+		return gcLF
+	case r == 0x200D:
+		return gcZWJ
+	case r == 0x200C, 0x1F3FB <= r && r <= 0x1F3FF, 0xE0020 <= r && r <= 0xE007F:
+		// ZWNJ, emoji modifiers and tags.
+		return gcExtend
+	case 0x1F1E6 <= r && r <= 0x1F1FF:
+		return gcRI
+	case 0x1100 <= r && r <= 0x115F, 0xA960 <= r && r <= 0xA97C:
+		return gcL
+	case 0x1160 <= r && r <= 0x11A7, 0xD7B0 <= r && r <= 0xD7C6:
+		return gcV
+	case 0x11A8 <= r && r <= 0x11FF, 0xD7CB <= r && r <= 0xD7FB:
+		return gcT
+	case 0xAC00 <= r && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return gcLV
+		}
+		return gcLVT
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return gcExtend
+	case unicode.Is(unicode.Mc, r):
+		return gcSpacingMark
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp):
+		return gcControl
+	case isPictographic(r):
+		return gcPict
+	}
+	return gcOther
+}
+
+// isPictographic approximates the Extended_Pictographic property.
+func isPictographic(r rune) bool {
+	switch {
+	case r == 0xA9, r == 0xAE, r == 0x203C, r == 0x2049, r == 0x2122, r == 0x2139:
+		return true
+	case 0x2194 <= r && r <= 0x21AA, 0x2300 <= r && r <= 0x23FF, 0x25A0 <= r && r <= 0x27BF:
+		return true
+	case 0x2B00 <= r && r <= 0x2BFF, 0x1F000 <= r && r <= 0x1FAFF:
+		return true
+	}
+	return false
+}
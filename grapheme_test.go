@@ -0,0 +1,33 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanGraphemes(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Tokens []string
+	}{
+		{"abc", []string{"a", "b", "c"}},
+		{"éx", []string{"é", "x"}},
+		{"a\r\nb\n\r", []string{"a", "\r\n", "b", "\n", "\r"}},
+		{"\uac01\uac00\u1100\u1161\u11a8", []string{"\uac01", "\uac00", "\u1100\u1161\u11a8"}},
+		{"🇯🇵🇫🇷🇺", []string{"🇯🇵", "🇫🇷", "🇺"}},
+		{"👩‍👩‍👧!", []string{"👩‍👩‍👧", "!"}},
+		{"👍🏽👍", []string{"👍🏽", "👍"}},
+		{"a‍b", []string{"a‍", "b"}},
+		{"क्षि", []string{"क्", "षि"}},
+		{"a\xffb", []string{"a", "\xff", "b"}},
+		{"", nil},
+	} {
+		tokens, err := splitAll(i.Input, ScanGraphemes)
+		if err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		}
+		if strings.Join(tokens, "|") != strings.Join(i.Tokens, "|") || len(tokens) != len(i.Tokens) {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, tokens)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package stream
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+)
+
+// NewResponseEnumerator creates a ScanEnumerator over the body of resp,
+// which net/http has already decoded from any chunked transfer coding.
+// When limit > 0, a body longer than limit bytes fails with
+// ErrBodyTooLarge, as soon as its Content-Length is known to exceed the
+// limit. The caller remains responsible for closing the body.
+func NewResponseEnumerator(resp *http.Response, split bufio.SplitFunc, limit int64, opts ...ScanOption) *ScanEnumerator {
+	return NewScanEnumeratorWith(limitBody(resp.Body, resp.ContentLength, limit), split, opts...)
+}
+
+// NewRequestEnumerator is like NewResponseEnumerator but for the body of
+// an incoming request.
+func NewRequestEnumerator(req *http.Request, split bufio.SplitFunc, limit int64, opts ...ScanOption) *ScanEnumerator {
+	return NewScanEnumeratorWith(limitBody(req.Body, req.ContentLength, limit), split, opts...)
+}
+
+// NewChunkedEnumerator creates a ScanEnumerator over a body in the HTTP
+// chunked transfer coding read directly from r, e.g. from a hijacked
+// connection. The end of input is the last, empty chunk.
+func NewChunkedEnumerator(r io.Reader, split bufio.SplitFunc, opts ...ScanOption) *ScanEnumerator {
+	return NewScanEnumeratorWith(httputil.NewChunkedReader(r), split, opts...)
+}
+
+func limitBody(body io.Reader, length, limit int64) io.Reader {
+	if limit <= 0 {
+		return body
+	}
+	return &bodyReader{body, limit, limit, length > limit}
+}
+
+// bodyReader fails once more than Limit bytes are read from R, or at
+// once when Over is true. Left is the number of bytes left. The bytes
+// within the limit are returned before the error.
+type bodyReader struct {
+	R           io.Reader
+	Left, Limit int64
+	Over        bool
+}
+
+func (r *bodyReader) Read(p []byte) (int, error) {
+	if r.Over {
+		return 0, ErrBodyTooLarge(r.Limit)
+	}
+	// Read one more byte than allowed to detect an overlong body.
+	if int64(len(p)) > r.Left+1 {
+		p = p[:r.Left+1]
+	}
+	n, err := r.R.Read(p)
+	if int64(n) > r.Left {
+		// Fail on the next Read.
+		n, r.Over = int(r.Left), true
+		r.Left = 0
+		return n, nil
+	}
+	r.Left -= int64(n)
+	return n, err
+}
+
+// ErrBodyTooLarge reports a body longer than the limit.
+type ErrBodyTooLarge int64
+
+func (e ErrBodyTooLarge) Error() string { return fmt.Sprintf("body larger than %d bytes", int64(e)) }
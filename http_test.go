@@ -0,0 +1,72 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"strings"
+	"testing"
+)
+
+func TestResponseEnumerator(t *testing.T) {
+	list := Seq(Match("("), Star(Match("x")), Match(")"), EOF)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := r.URL.Query().Get("body")
+		if r.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		}
+		for _, s := range strings.SplitAfter(body, " ") {
+			io.WriteString(w, s)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer srv.Close()
+	for _, i := range []struct {
+		Body    string
+		Chunked bool
+		Limit   int64
+		Err     error
+	}{
+		{"( x x )", false, 0, nil},
+		{"( x x )", true, 0, nil},
+		{"( x x )", false, 7, nil},
+		{"( x x )", true, 7, nil},
+		{"( x x )", false, 6, ErrBodyTooLarge(6)},
+		{"( x x )", true, 6, ErrBodyTooLarge(6)},
+	} {
+		url := srv.URL + "?body=" + strings.ReplaceAll(i.Body, " ", "+")
+		if i.Chunked {
+			url += "&chunked=1"
+		}
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Run(NewResponseEnumerator(resp, bufio.ScanWords, i.Limit), list)
+		resp.Body.Close()
+		if !errors.Is(err, i.Err) && err != i.Err {
+			t.Errorf("body %q chunked %v limit %d: expect error %v; got %v", i.Body, i.Chunked, i.Limit, i.Err, err)
+		}
+	}
+
+	// The bytes within the limit come before the error.
+	b, err := io.ReadAll(limitBody(strings.NewReader("abcdef"), -1, 4))
+	if string(b) != "abcd" || err != ErrBodyTooLarge(4) {
+		t.Errorf("expect %q and ErrBodyTooLarge; got %q and %v", "abcd", b, err)
+	}
+}
+
+func TestChunkedEnumerator(t *testing.T) {
+	var body strings.Builder
+	w := httputil.NewChunkedWriter(&body)
+	io.WriteString(w, "( x")
+	io.WriteString(w, " x )")
+	w.Close()
+	if err := Run(NewChunkedEnumerator(strings.NewReader(body.String()), bufio.ScanWords), Seq(Match("("), Star(Match("x")), Match(")"), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
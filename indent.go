@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// The tokens that IndentSplit adds to those of its inner SplitState.
+// Indent and Dedent are the ASCII shift out and shift in characters.
+const (
+	Indent  = "\x0e" // a line indented deeper than the previous one.
+	Dedent  = "\x0f" // the end of an indented block.
+	Newline = "\n"   // the end of a line.
+)
+
+// IndentSplit is a SplitState for indentation-sensitive formats, like
+// the tokenizer of Python. Each non-blank line is split by Inner, which
+// only sees the line and treats its end as the end of input, and is
+// followed by a Newline token. A line indented deeper than the previous
+// one starts with an Indent token, and a line indented less starts with
+// a Dedent token for each block it closes; blocks left open are closed
+// at the end of input. A tab indents to the next multiple of 8 columns.
+// A line that closes blocks but does not line up with an outer one fails
+// with ErrDedent. Since a line is split as a whole, it must fit in the
+// buffer.
+type IndentSplit struct {
+	Inner SplitState
+}
+
+func (s IndentSplit) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	return indentState{s.Inner, []int{0}, 0, false}.Next(data, atEOF)
+}
+
+// indentState is the state of IndentSplit. Levels is the stack of the
+// indentation of the open blocks, which is never modified in place.
+// Pending is the number of Dedent tokens still to emit, and InLine is
+// true after the indentation of a line.
+type indentState struct {
+	Inner   SplitState
+	Levels  []int
+	Pending int
+	InLine  bool
+}
+
+func (s indentState) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	if s.Pending > 0 {
+		s.Pending--
+		return s, 0, []byte(Dedent), nil
+	}
+	end := bytes.IndexByte(data, '\n')
+	next := end + 1
+	if end < 0 {
+		if !atEOF {
+			return s, 0, nil, nil
+		}
+		end, next = len(data), len(data)
+	}
+	line := bytes.TrimSuffix(data[:end], []byte("\r"))
+	if s.InLine {
+		inner, advance, token, err := s.Inner.Next(line, true)
+		s.Inner = inner
+		if err != nil || advance > 0 || token != nil {
+			return s, advance, token, err
+		}
+		s.InLine = false
+		return s, next, []byte(Newline), nil
+	}
+	if len(data) == 0 {
+		// The end of input.
+		if len(s.Levels) > 1 {
+			s.Levels = s.Levels[:len(s.Levels)-1]
+			return s, 0, []byte(Dedent), nil
+		}
+		return s, 0, nil, nil
+	}
+	col, i := 0, 0
+	for ; i < len(line) && (line[i] == ' ' || line[i] == '\t'); i++ {
+		if line[i] == '\t' {
+			col += 8 - col%8
+		} else {
+			col++
+		}
+	}
+	if i == len(line) {
+		// A blank line.
+		return s, next, nil, nil
+	}
+	s.InLine = true
+	top := s.Levels[len(s.Levels)-1]
+	switch {
+	case col > top:
+		s.Levels = append(s.Levels[:len(s.Levels):len(s.Levels)], col)
+		return s, i, []byte(Indent), nil
+	case col < top:
+		n := len(s.Levels)
+		for n > 1 && s.Levels[n-1] > col {
+			n--
+		}
+		if s.Levels[n-1] != col {
+			return s, 0, nil, ErrDedent(col)
+		}
+		s.Pending = len(s.Levels) - n - 1
+		s.Levels = s.Levels[:n]
+		return s, i, []byte(Dedent), nil
+	}
+	return s, i, nil, nil
+}
+
+// ErrDedent reports a line that closes indented blocks but does not line
+// up with an outer block.
+type ErrDedent int
+
+func (e ErrDedent) Error() string {
+	return fmt.Sprintf("indentation of %s does not match any outer block", plural(int(e), "column"))
+}
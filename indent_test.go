@@ -0,0 +1,33 @@
+package stream
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestIndentSplit(t *testing.T) {
+	names := strings.NewReplacer(Indent, "INDENT", Dedent, "DEDENT", Newline, "NL")
+	for _, i := range []struct {
+		Input  string
+		Tokens string
+		Err    string
+	}{
+		{"if x:\n  a b\n\n  if y:\n\tc\nd\n", "if|x:|NL|INDENT|a|b|NL|if|y:|NL|INDENT|c|NL|DEDENT|DEDENT|d|NL", ""},
+		{"a\n  b\n    c", "a|NL|INDENT|b|NL|INDENT|c|NL|DEDENT|DEDENT", ""},
+		{"a\r\n  b  \r\n  \r\nc\r\n", "a|NL|INDENT|b|NL|DEDENT|c|NL", ""},
+		{"a\n    b\n  c\n", "a|NL|INDENT|b|NL", "indentation of 2 columns does not match any outer block"},
+		{"", "", ""},
+		{"\n\n", "", ""},
+	} {
+		tokens, err := splitAll(i.Input, StatefulSplitFunc(IndentSplit{SplitFuncState(bufio.ScanWords)}))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if got := names.Replace(strings.Join(tokens, "|")); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package stream
+
+import "bytes"
+
+// IniSplit is a SplitState for INI and Java properties files. A line
+// makes the tokens:
+//
+//   - "[name]" for a section header, with the space around name removed;
+//   - "#text" for a comment starting with "#", ";" or "!";
+//   - the key and then "=value" for a key-value pair, where the key ends
+//     at the first "=", ":" or space not escaped by a backslash, as in
+//     java.util.Properties; space around the separator is dropped, and
+//     a key alone has the empty value "=".
+//
+// Keys and values are trimmed of space. A value line that ends with an
+// odd number of backslashes continues on the next line, whose leading
+// space is dropped. Other escapes are kept as they are. Blank lines are
+// skipped. Since a whole logical line is needed to make its tokens, it
+// must fit in the buffer.
+type IniSplit struct{}
+
+func (s IniSplit) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	start := 0
+	for start < len(data) && (isIniSpace(data[start]) || data[start] == '\n' || data[start] == '\r') {
+		start++
+	}
+	if start == len(data) {
+		return s, start, nil, nil
+	}
+	end := bytes.IndexByte(data[start:], '\n')
+	if end < 0 && !atEOF {
+		return s, start, nil, nil
+	}
+	next := len(data)
+	if end >= 0 {
+		end += start
+		next = end + 1
+	} else {
+		end = len(data)
+	}
+	line := bytes.TrimRight(data[start:end], " \t\f\r")
+	switch line[0] {
+	case '#', ';', '!':
+		return s, next, append([]byte{'#'}, bytes.TrimSpace(line[1:])...), nil
+	case '[':
+		if line[len(line)-1] == ']' {
+			return s, next, append(append([]byte{'['}, bytes.TrimSpace(line[1:len(line)-1])...), ']'), nil
+		}
+	}
+	key := len(line)
+	for i := 0; i < len(line); i++ {
+		if c := line[i]; c == '\\' {
+			i++
+		} else if c == '=' || c == ':' || isIniSpace(c) {
+			key = i
+			break
+		}
+	}
+	sep := key
+	for sep < len(line) && isIniSpace(line[sep]) {
+		sep++
+	}
+	if sep < len(line) && (line[sep] == '=' || line[sep] == ':') {
+		sep++
+	}
+	return iniValue{}, start + sep, line[:key], nil
+}
+
+// iniValue is the state of IniSplit before the value of a key.
+type iniValue struct{}
+
+func (s iniValue) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	value := []byte{'='}
+	i := 0
+	for {
+		end := bytes.IndexByte(data[i:], '\n')
+		if end < 0 && !atEOF {
+			return s, 0, nil, nil
+		}
+		next := len(data)
+		if end >= 0 {
+			end += i
+			next = end + 1
+		} else {
+			end = len(data)
+		}
+		line := bytes.TrimLeft(bytes.TrimRight(data[i:end], "\r"), " \t\f")
+		if !iniContinued(line) || next == len(data) && end == len(data) {
+			line = bytes.TrimRight(line, " \t\f")
+			if iniContinued(line) {
+				line = line[:len(line)-1]
+			}
+			return IniSplit{}, next, append(value, line...), nil
+		}
+		value = append(value, line[:len(line)-1]...)
+		i = next
+	}
+}
+
+// iniContinued tells whether line ends with an odd number of
+// backslashes.
+func iniContinued(line []byte) bool {
+	n := 0
+	for n < len(line) && line[len(line)-1-n] == '\\' {
+		n++
+	}
+	return n%2 == 1
+}
+
+func isIniSpace(b byte) bool { return b == ' ' || b == '\t' || b == '\f' }
@@ -0,0 +1,29 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIniSplit(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Tokens string
+	}{
+		{"; settings\n[ main ]\nname = demo\r\n\n  port:8080\n", "#settings|[main]|name|=demo|port|=8080"},
+		{"# props\nkey value with space\nflag\n! bang", "#props|key|=value with space|flag|=|#bang"},
+		{"path = a, \\\n    b, \\\n    c\nnext=1", "path|=a, b, c|next|=1"},
+		{"odd = a\\\\\nx = y \\", `odd|=a\\|x|=y `},
+		{"[broken\n", "[broken|="},
+		{"url http://x\nurl2 = http://y\na\\=b:c=d", `url|=http://x|url2|=http://y|a\=b|=c=d`},
+		{"", ""},
+	} {
+		tokens, err := splitAll(i.Input, StatefulSplitFunc(IniSplit{}))
+		if err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+}
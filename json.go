@@ -0,0 +1,86 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// NewJSONEnumerator creates an Enumerator over the JSON tokens read
+// from r (see json.Decoder.Token), which may hold a sequence of JSON
+// values. Each token is encoded as follows:
+//
+//   - a delimiter as itself, e.g. "{" or "]";
+//   - a string, including an object key, as its JSON encoding with the
+//     quotes, e.g. `"a\n"`, so that it cannot be mistaken for other
+//     tokens;
+//   - a number as it appears in the input;
+//   - a literal as "true", "false" or "null".
+//
+// Commas and colons are checked by the decoder and not presented. Input
+// that ends inside an array or object fails with io.ErrUnexpectedEOF.
+func NewJSONEnumerator(r io.Reader) Enumerator {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &jsonEnumerator{dec: dec}
+}
+
+// jsonEnumerator implements NewJSONEnumerator(). token is the current
+// token, valid iff have is true, and index is its position. depth is the
+// number of unclosed arrays and objects.
+type jsonEnumerator struct {
+	dec          *json.Decoder
+	token        []byte
+	have         bool
+	index, depth int
+}
+
+func (e *jsonEnumerator) Step(it Iteratee) (Iteratee, error) {
+	if !e.have {
+		t, err := e.dec.Token()
+		if err == io.EOF && e.depth > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		if err == io.EOF {
+			return nil, it.Final()
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			e.depth++
+		case json.Delim('}'), json.Delim(']'):
+			e.depth--
+		}
+		e.token, e.have = encodeJSONToken(t), true
+	}
+	next, read, err := it.Next(e.token)
+	if err != nil {
+		return nil, WrapTokenErrorAt(e.token, -1, e.index, err)
+	}
+	if read {
+		e.index++
+		e.have = false
+	}
+	return next, nil
+}
+
+func encodeJSONToken(t json.Token) []byte {
+	switch t := t.(type) {
+	case json.Delim:
+		return []byte(t.String())
+	case string:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		enc.Encode(t)
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	case json.Number:
+		return []byte(t)
+	case bool:
+		return strconv.AppendBool(nil, t)
+	}
+	return []byte("null")
+}
@@ -0,0 +1,44 @@
+package stream
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONEnumerator(t *testing.T) {
+	var tokens []string
+	all := Star(Capture(Skip, appendTo(&tokens)))
+	err := Run(NewJSONEnumerator(strings.NewReader(`{"a": [1, 2.5e3, true, null], "b\n<": {}} "x" -0`)), all)
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	expected := []string{"{", `"a"`, "[", "1", "2.5e3", "true", "null", "]", `"b\n<"`, "{", "}", "}", `"x"`, "-0"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("expect %q; got %q", expected, tokens)
+	}
+
+	if err := Run(NewJSONEnumerator(strings.NewReader(`[{"a": 1}`)), Star(Skip)); err != io.ErrUnexpectedEOF {
+		t.Errorf("expect io.ErrUnexpectedEOF; got %v", err)
+	}
+
+	// Objects with a single numeric field "n".
+	object := Seq(Match("{"), Match(`"n"`), Float(), Match("}"))
+	for _, i := range []struct {
+		Input, Err string
+	}{
+		{`{"n": 1} {"n": -2}`, ""},
+		{`{"n": "1"}`, `token "\"1\"" (token #2): expect number`},
+		{`{"n": 1, "m": 2}`, `token "\"m\"" (token #3): expect "}"`},
+		{`{"n": 1`, `unexpected EOF`},
+		{`{"n" 1}`, `invalid character '1' after object key`},
+	} {
+		err := Run(NewJSONEnumerator(strings.NewReader(i.Input)), Seq(Star(object), EOF))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
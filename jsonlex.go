@@ -0,0 +1,202 @@
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// JSONSplit is a SplitState for a JSON lexer. The tokens are the
+// punctuation "{", "}", "[", "]", ":" and ",", strings with their quotes
+// and escapes, numbers and the literals true, false and null, all
+// exactly as in the input; space between them is skipped. Unlike
+// NewJSONEnumerator, it does not check the structure of the input, so
+// it also splits a stream of JSON values or a fragment. Invalid tokens
+// fail with ErrJSON, and a string cut short by the end of input with
+// io.ErrUnexpectedEOF. Use it as a SplitStateAt to locate the errors.
+type JSONSplit struct{}
+
+func (s JSONSplit) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	i := 0
+	for i < len(data) && isJSONSpace(data[i]) {
+		i++
+	}
+	if i == len(data) {
+		return s, i, nil, nil
+	}
+	var (
+		end int
+		err error
+	)
+	switch b := data[i]; {
+	case bytes.IndexByte([]byte("{}[]:,"), b) >= 0:
+		return s, i + 1, data[i : i+1], nil
+	case b == '"':
+		end, err = scanJSONString(data[i:])
+	case b == '-' || '0' <= b && b <= '9':
+		end, err = scanJSONNumber(data[i:])
+	case 'a' <= b && b <= 'z':
+		end, err = scanJSONLiteral(data[i:])
+	default:
+		return s, 0, nil, ErrJSON(fmt.Sprintf("unexpected byte %q", b))
+	}
+	if err != nil {
+		return s, 0, nil, err
+	}
+	if end < 0 {
+		// The token may go on after data.
+		if !atEOF {
+			return s, i, nil, nil
+		}
+		if data[i] == '"' {
+			return s, 0, nil, io.ErrUnexpectedEOF
+		}
+		end = len(data) - i
+		if _, err := scanJSONToken(data[i:]); err != nil {
+			return s, 0, nil, err
+		}
+	}
+	return s, i + end, data[i : i+end], nil
+}
+
+// NextAt makes JSONSplit a SplitStateAt, whose errors are SplitErrs at
+// the start of the invalid token.
+func (s JSONSplit) NextAt(offset int, data []byte, atEOF bool) (SplitStateAt, int, []byte, error) {
+	_, advance, token, err := s.Next(data, atEOF)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		i := 0
+		for i < len(data) && isJSONSpace(data[i]) {
+			i++
+		}
+		err = SplitErr{offset + i, err}
+	}
+	return s, advance, token, err
+}
+
+// scanJSONToken checks a number or literal that is the whole of tok.
+func scanJSONToken(tok []byte) (int, error) {
+	if tok[0] == '-' || '0' <= tok[0] && tok[0] <= '9' {
+		if !validJSONNumber(tok) {
+			return 0, ErrJSON(fmt.Sprintf("invalid number %q", tok))
+		}
+		return len(tok), nil
+	}
+	switch string(tok) {
+	case "true", "false", "null":
+		return len(tok), nil
+	}
+	return 0, ErrJSON(fmt.Sprintf("invalid literal %q", tok))
+}
+
+// scanJSONString returns the size of the string at the start of data,
+// or -1 if it does not end in data.
+func scanJSONString(data []byte) (int, error) {
+	for i := 1; i < len(data); i++ {
+		switch b := data[i]; {
+		case b == '"':
+			return i + 1, nil
+		case b < 0x20:
+			return 0, ErrJSON("control character in string")
+		case b == '\\':
+			if i+1 == len(data) {
+				return -1, nil
+			}
+			i++
+			switch data[i] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			case 'u':
+				for k := 1; k <= 4; k++ {
+					if i+k == len(data) {
+						return -1, nil
+					}
+					if !isHex(data[i+k]) {
+						return 0, ErrJSON("invalid escape in string")
+					}
+				}
+				i += 4
+			default:
+				return 0, ErrJSON("invalid escape in string")
+			}
+		}
+	}
+	return -1, nil
+}
+
+// scanJSONNumber returns the size of the number at the start of data, or
+// -1 if it may go on after data.
+func scanJSONNumber(data []byte) (int, error) {
+	end := 0
+	for end < len(data) && bytes.IndexByte([]byte("+-.0123456789eE"), data[end]) >= 0 {
+		end++
+	}
+	return scanJSONEnd(data, end)
+}
+
+// scanJSONLiteral returns the size of the literal at the start of data,
+// or -1 if it may go on after data.
+func scanJSONLiteral(data []byte) (int, error) {
+	end := 0
+	for end < len(data) && 'a' <= data[end] && data[end] <= 'z' {
+		end++
+	}
+	return scanJSONEnd(data, end)
+}
+
+// scanJSONEnd checks the number or literal data[:end], which must be
+// followed by a delimiter.
+func scanJSONEnd(data []byte, end int) (int, error) {
+	if end == len(data) {
+		return -1, nil
+	}
+	if b := data[end]; !isJSONSpace(b) && bytes.IndexByte([]byte("{}[]:,\""), b) < 0 {
+		return 0, ErrJSON(fmt.Sprintf("unexpected byte %q after %q", b, data[:end]))
+	}
+	return scanJSONToken(data[:end])
+}
+
+// validJSONNumber tells whether tok is a number in JSON syntax.
+func validJSONNumber(tok []byte) bool {
+	i := 0
+	digits := func() int {
+		n := 0
+		for i < len(tok) && '0' <= tok[i] && tok[i] <= '9' {
+			i, n = i+1, n+1
+		}
+		return n
+	}
+	if i < len(tok) && tok[i] == '-' {
+		i++
+	}
+	if i < len(tok) && tok[i] == '0' {
+		i++
+	} else if digits() == 0 {
+		return false
+	}
+	if i < len(tok) && tok[i] == '.' {
+		i++
+		if digits() == 0 {
+			return false
+		}
+	}
+	if i < len(tok) && (tok[i] == 'e' || tok[i] == 'E') {
+		i++
+		if i < len(tok) && (tok[i] == '+' || tok[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return false
+		}
+	}
+	return i == len(tok)
+}
+
+func isJSONSpace(b byte) bool { return b == ' ' || b == '\t' || b == '\n' || b == '\r' }
+
+func isHex(b byte) bool {
+	return '0' <= b && b <= '9' || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F'
+}
+
+// ErrJSON reports invalid JSON.
+type ErrJSON string
+
+func (e ErrJSON) Error() string { return "invalid JSON: " + string(e) }
@@ -0,0 +1,37 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONSplit(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Tokens string
+		Err    string
+	}{
+		{`{"a": [1, -2.5e+3, true], "b\"é": null}`, `{|"a"|:|[|1|,|-2.5e+3|,|true|]|,|"b\"é"|:|null|}`, ""},
+		{" 0 false\n\"x\" ", `0|false|"x"`, ""},
+		{"[01]", "[", `invalid JSON: invalid number "01"`},
+		{"[1.]", "[", `invalid JSON: invalid number "1."`},
+		{"[nul]", "[", `invalid JSON: invalid literal "nul"`},
+		{"true1", "", `invalid JSON: unexpected byte '1' after "true"`},
+		{`"a\x"`, "", "invalid JSON: invalid escape in string"},
+		{"\"a\nb\"", "", "invalid JSON: control character in string"},
+		{"@", "", "invalid JSON: unexpected byte '@'"},
+		{`["ab`, "[", "unexpected EOF"},
+		{"1e5", "1e5", ""},
+		{"nul", "", `invalid JSON: invalid literal "nul"`},
+	} {
+		tokens, err := splitAll(i.Input, StatefulSplitFunc(JSONSplit{}))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package stream
+
+// Not succeeds without consuming anything when it fails on the next
+// token, and fails with ErrUnexpected otherwise. Only the next token is
+// examined: it is considered to accept the token as long as it does not
+// fail on it. At the end of input, Not succeeds iff it does not accept
+// the end of input.
+func Not(it Iteratee) Iteratee {
+	return notI{it}
+}
+
+// notI implements Not().
+type notI struct {
+	A Iteratee
+}
+
+func (it notI) Final() error {
+	if it.A.Final() != nil {
+		return nil
+	}
+	return ErrUnexpected
+}
+
+func (it notI) Next(token []byte) (Iteratee, bool, error) {
+	if _, _, err := step(it.A, token); err != nil {
+		return nil, false, nil
+	}
+	return nil, false, ErrUnexpected
+}
+
+// Peek succeeds without consuming anything when it accepts the next
+// token, and fails with its error otherwise. Like Not, only the next
+// token is examined.
+func Peek(it Iteratee) Iteratee {
+	return peekI{it}
+}
+
+// peekI implements Peek().
+type peekI struct {
+	A Iteratee
+}
+
+func (it peekI) Final() error { return it.A.Final() }
+func (it peekI) Next(token []byte) (Iteratee, bool, error) {
+	_, _, err := step(it.A, token)
+	return nil, false, err
+}
+
+// If continues with then when pred returns true on the next token and
+// with els otherwise, without consuming the token. At the end of input,
+// it continues with els. A nil branch finishes immediately.
+func If(pred func(token []byte) bool, then, els Iteratee) Iteratee {
+	return ifI{pred, then, els}
+}
+
+// ifI implements If().
+type ifI struct {
+	Pred       func([]byte) bool
+	Then, Else Iteratee
+}
+
+func (it ifI) Final() error {
+	if it.Else == nil {
+		return nil
+	}
+	return it.Else.Final()
+}
+
+func (it ifI) Next(token []byte) (Iteratee, bool, error) {
+	if it.Pred(token) {
+		return it.Then, false, nil
+	}
+	return it.Else, false, nil
+}
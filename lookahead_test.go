@@ -0,0 +1,79 @@
+package stream
+
+import "testing"
+
+func TestNot(t *testing.T) {
+	keyword := Or(Match("if"), Match("else"))
+	ident := Seq(Not(keyword), Skip)
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		OK    bool
+	}{
+		{"x", ident, true},
+		{"if", ident, false},
+		{"else", ident, false},
+		{"", Not(Match("x")), true},
+		{"", Not(SkipAny("x")), false},
+		// Nothing is consumed.
+		{"a b", Seq(Not(Match("b")), Match("a"), Match("b"), EOF), true},
+	} {
+		err := runWords(i.Input, i.It)
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+}
+
+func TestPeek(t *testing.T) {
+	// A list item is anything up to a closing bracket.
+	list := Seq(Match("["), Star(Seq(Not(Match("]")), Skip)), Peek(Match("]")), Match("]"), EOF)
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		OK    bool
+	}{
+		{"[ a b ]", list, true},
+		{"[ a b", list, false},
+		{"a", Seq(Peek(Match("a")), Match("a"), EOF), true},
+		{"b", Peek(Match("a")), false},
+		{"", Peek(Match("a")), false},
+		{"", Peek(SkipAny("a")), true},
+	} {
+		err := runWords(i.Input, i.It)
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+}
+
+func TestIf(t *testing.T) {
+	isFlag := func(token []byte) bool { return len(token) > 0 && token[0] == '-' }
+	// A flag takes a value unless another flag follows.
+	args := Star(If(isFlag, Seq(Skip, If(isFlag, nil, Skip)), Match("x")))
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"", true},
+		{"x -a 1 -b -c 2 x", true},
+		{"-a -b 1", true},
+		{"-a", false},
+		{"x y", false},
+	} {
+		err := runWords(i.Input, Seq(args, EOF))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+
+	if err := If(isFlag, nil, Match("x")).Final(); err == nil || err.Error() != `expect "x"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MatchOneOf is like Match but accepts any one of ss. The error lists
+// all the accepted strings.
+func MatchOneOf(ss ...string) Iteratee {
+	set := make(map[string]bool, len(ss))
+	for _, s := range ss {
+		set[s] = true
+	}
+	return matchOneOfI{set, ss}
+}
+
+// matchOneOfI implements MatchOneOf(). List keeps the original order
+// for error messages.
+type matchOneOfI struct {
+	Set  map[string]bool
+	List []string
+}
+
+func (it matchOneOfI) Final() error { return it.err() }
+func (it matchOneOfI) Next(token []byte) (Iteratee, bool, error) {
+	if it.Set[string(token)] {
+		return nil, true, nil
+	}
+	return nil, false, it.err()
+}
+
+func (it matchOneOfI) err() error {
+	quoted := make([]string, len(it.List))
+	for i, s := range it.List {
+		quoted[i] = strconv.Quote(s)
+	}
+	return ErrExpect("one of " + strings.Join(quoted, ", "))
+}
+
+// MatchFunc accepts the next token if pred returns true on it. desc
+// describes the accepted tokens in errors, as in ErrExpect.
+func MatchFunc(pred func(token []byte) bool, desc string) Iteratee {
+	return matchFuncI{pred, desc}
+}
+
+// matchFuncI implements MatchFunc().
+type matchFuncI struct {
+	Pred func([]byte) bool
+	Desc string
+}
+
+func (it matchFuncI) Final() error { return ErrExpect(it.Desc) }
+func (it matchFuncI) Next(token []byte) (Iteratee, bool, error) {
+	if it.Pred(token) {
+		return nil, true, nil
+	}
+	return nil, false, ErrExpect(it.Desc)
+}
+
+// MatchRegexp accepts the next token if it contains a match of re. Use
+// ^ and $ to match the whole token.
+func MatchRegexp(re *regexp.Regexp) Iteratee {
+	return MatchFunc(re.Match, "token matching /"+re.String()+"/")
+}
+
+// MustMatchRegexp is like MatchRegexp but compiles expr first. It
+// panics if expr cannot be parsed.
+func MustMatchRegexp(expr string) Iteratee {
+	return MatchRegexp(regexp.MustCompile(expr))
+}
+
+// MatchFold is like Match but compares under Unicode case folding (see
+// bytes.EqualFold).
+func MatchFold(s string) Iteratee {
+	return matchFoldI(s)
+}
+
+// matchFoldI implements MatchFold().
+type matchFoldI string
+
+func (it matchFoldI) Final() error { return it.err() }
+func (it matchFoldI) Next(token []byte) (Iteratee, bool, error) {
+	if bytes.EqualFold(token, []byte(it)) {
+		return nil, true, nil
+	}
+	return nil, false, it.err()
+}
+
+func (it matchFoldI) err() error { return ErrExpect(strconv.Quote(string(it)) + " in any case") }
+
+// MatchPrefix accepts the next token if it begins with p.
+func MatchPrefix(p string) Iteratee {
+	return CutPrefix(p, nil)
+}
+
+// MatchSuffix accepts the next token if it ends with s.
+func MatchSuffix(s string) Iteratee {
+	return CutSuffix(s, nil)
+}
+
+// CutPrefix is like MatchPrefix but also calls fn, if not nil, with a
+// copy of the rest of the token after p.
+func CutPrefix(p string, fn func(rest []byte)) Iteratee {
+	return affixI{p, false, fn}
+}
+
+// CutSuffix is like MatchSuffix but also calls fn, if not nil, with a
+// copy of the rest of the token before s.
+func CutSuffix(s string, fn func(rest []byte)) Iteratee {
+	return affixI{s, true, fn}
+}
+
+// affixI implements CutPrefix() and CutSuffix().
+type affixI struct {
+	Affix  string
+	Suffix bool
+	Fn     func([]byte)
+}
+
+func (it affixI) Final() error { return it.err() }
+func (it affixI) Next(token []byte) (Iteratee, bool, error) {
+	var (
+		rest []byte
+		ok   bool
+	)
+	if it.Suffix {
+		rest, ok = bytes.CutSuffix(token, []byte(it.Affix))
+	} else {
+		rest, ok = bytes.CutPrefix(token, []byte(it.Affix))
+	}
+	if !ok {
+		return nil, false, it.err()
+	}
+	if it.Fn != nil {
+		it.Fn(append([]byte(nil), rest...))
+	}
+	return nil, true, nil
+}
+
+func (it affixI) err() error {
+	kind := "prefix"
+	if it.Suffix {
+		kind = "suffix"
+	}
+	return ErrExpect("token with " + kind + " " + strconv.Quote(it.Affix))
+}
+
+// Int accepts a decimal integer with an optional sign that fits in 64
+// bits.
+func Int() Iteratee {
+	return classI{"integer", func(token []byte) error {
+		_, err := strconv.ParseInt(string(token), 10, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return ErrExpect("integer within 64 bits")
+		}
+		return err
+	}}
+}
+
+// Float accepts a number in any syntax accepted by strconv.ParseFloat,
+// including integers, exponents, "Inf" and "NaN", that fits in 64 bits.
+func Float() Iteratee {
+	return classI{"number", func(token []byte) error {
+		_, err := strconv.ParseFloat(string(token), 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return ErrExpect("number within 64 bits")
+		}
+		return err
+	}}
+}
+
+// Identifier accepts a letter or underscore followed by any number of
+// letters, digits and underscores, as in Go.
+func Identifier() Iteratee {
+	return classI{"identifier", func(token []byte) error {
+		for i, r := range string(token) {
+			if r == utf8.RuneError || !(r == '_' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r)) {
+				return ErrUnexpected
+			}
+		}
+		if len(token) == 0 {
+			return ErrUnexpected
+		}
+		return nil
+	}}
+}
+
+// QuotedString accepts a token that starts and ends with quote, where
+// any quote or backslash in between is escaped by a backslash. The
+// escapes are not interpreted.
+func QuotedString(quote byte) Iteratee {
+	q := strconv.QuoteRuneToASCII(rune(quote))
+	return classI{"string quoted by " + q, func(token []byte) error {
+		if len(token) == 0 || token[0] != quote {
+			return ErrUnexpected
+		}
+		for i := 1; i < len(token); i++ {
+			switch token[i] {
+			case '\\':
+				i++
+			case quote:
+				if i < len(token)-1 {
+					return ErrExpect("escaped " + q + " inside string")
+				}
+				return nil
+			}
+		}
+		return ErrExpect("closing " + q)
+	}}
+}
+
+// classI implements the token class matchers. Check returns nil when
+// the token is accepted. Any error from Check other than an ErrExpect
+// is reported as ErrExpect(Desc).
+type classI struct {
+	Desc  string
+	Check func([]byte) error
+}
+
+func (it classI) Final() error { return ErrExpect(it.Desc) }
+func (it classI) Next(token []byte) (Iteratee, bool, error) {
+	err := it.Check(token)
+	if err == nil {
+		return nil, true, nil
+	}
+	if e, ok := err.(ErrExpect); ok {
+		return nil, false, e
+	}
+	return nil, false, ErrExpect(it.Desc)
+}
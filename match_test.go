@@ -0,0 +1,183 @@
+package stream
+
+import "testing"
+
+func TestMatchOneOf(t *testing.T) {
+	verb := Seq(MatchOneOf("GET", "PUT", "POST"), EOF)
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"GET", true},
+		{"POST", true},
+		{"get", false},
+		{"", false},
+	} {
+		err := runWords(i.Input, verb)
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+
+	if err := MatchOneOf("a", "b").Final(); err == nil || err.Error() != `expect one of "a", "b"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMatchFunc(t *testing.T) {
+	digits := MatchFunc(func(token []byte) bool {
+		for _, b := range token {
+			if b < '0' || b > '9' {
+				return false
+			}
+		}
+		return len(token) > 0
+	}, "digits")
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"123 0", true},
+		{"12a", false},
+		{"1", false},
+	} {
+		err := runWords(i.Input, Seq(digits, digits, EOF))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+
+	if err := digits.Final(); err == nil || err.Error() != "expect digits" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMatchRegexp(t *testing.T) {
+	ip := MustMatchRegexp(`^\d{1,3}(\.\d{1,3}){3}$`)
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"127.0.0.1", true},
+		{"10.0.0.256", true},
+		{"10.0.0", false},
+		{"a10.0.0.1", false},
+	} {
+		err := runWords(i.Input, Seq(ip, EOF))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+
+	if err := MustMatchRegexp(`^\d+$`).Final(); err == nil || err.Error() != `expect token matching /^\d+$/` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMatchFold(t *testing.T) {
+	for _, i := range []struct {
+		Input, S string
+		OK       bool
+	}{
+		{"Content-Length", "content-length", true},
+		{"SELECT", "select", true},
+		{"straße", "STRASSE", false},
+		{"ΣΑΣ", "σας", true},
+		{"selec", "select", false},
+	} {
+		err := runWords(i.Input, Seq(MatchFold(i.S), EOF))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+
+	if err := MatchFold("helo").Final(); err == nil || err.Error() != `expect "helo" in any case` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMatchPrefix(t *testing.T) {
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		OK    bool
+	}{
+		{"#comment", MatchPrefix("#"), true},
+		{"#", MatchPrefix("#"), true},
+		{"comment", MatchPrefix("#"), false},
+		{"file.go", MatchSuffix(".go"), true},
+		{"file.c", MatchSuffix(".go"), false},
+	} {
+		err := runWords(i.Input, Seq(i.It, EOF))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+
+	var code, name string
+	err := runWords("ERR404 main.go", Seq(
+		CutPrefix("ERR", func(rest []byte) { code = string(rest) }),
+		CutSuffix(".go", func(rest []byte) { name = string(rest) }),
+		EOF))
+	if err != nil || code != "404" || name != "main" {
+		t.Errorf("unexpected result %q %q %v", code, name, err)
+	}
+	if err := MatchSuffix(".go").Final(); err == nil || err.Error() != `expect token with suffix ".go"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestTokenClasses(t *testing.T) {
+	for _, i := range []struct {
+		It    Iteratee
+		Input string
+		Err   string
+	}{
+		{Int(), "42", ""},
+		{Int(), "-7", ""},
+		{Int(), "+0", ""},
+		{Int(), "4.2", "expect integer"},
+		{Int(), "0x10", "expect integer"},
+		{Int(), "99999999999999999999", "expect integer within 64 bits"},
+		{Float(), "4.2", ""},
+		{Float(), "-1e10", ""},
+		{Float(), "7", ""},
+		{Float(), "1e400", "expect number within 64 bits"},
+		{Float(), "x", "expect number"},
+		{Identifier(), "_x1", ""},
+		{Identifier(), "héllo", ""},
+		{Identifier(), "1x", "expect identifier"},
+		{Identifier(), "a-b", "expect identifier"},
+		{Identifier(), "", "expect identifier"},
+		{QuotedString('"'), `"a b"`, ""},
+		{QuotedString('"'), `""`, ""},
+		{QuotedString('"'), `"a\"b"`, ""},
+		{QuotedString('\''), `'a\\'`, ""},
+		{QuotedString('"'), `a`, `expect string quoted by '"'`},
+		{QuotedString('"'), `"a`, `expect closing '"'`},
+		{QuotedString('"'), `"a\"`, `expect closing '"'`},
+		{QuotedString('"'), `"a"b"`, `expect escaped '"' inside string`},
+		{QuotedString('"'), `"`, `expect closing '"'`},
+	} {
+		_, _, err := i.It.Next([]byte(i.Input))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+
+	if err := Int().Final(); err == nil || err.Error() != "expect integer" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// MergeEnumerator is an Enumerator over the tokens of several sources
+// read concurrently, in the order in which they arrive. The Iteratee is
+// given the end of input once all the sources have reached theirs.
+// Indices in errors are those in the merged input; offsets are not
+// reported.
+type MergeEnumerator struct {
+	items chan mergeItem
+	done  chan struct{}
+	once  sync.Once
+	left  int // the number of sources that have not ended.
+	// cur is the current token, valid iff have is true, and index is
+	// its position.
+	cur   mergeItem
+	have  bool
+	index int
+}
+
+// mergeItem is a token from source Origin, or the end of that source
+// with the error of its Enumerator if End is true.
+type mergeItem struct {
+	Origin int
+	Token  []byte
+	End    bool
+	Err    error
+}
+
+// Merge creates a MergeEnumerator reading from es, each in its own
+// goroutine; e.g. NewChanEnumerator or Lines can be used for channel or
+// reader sources. A source is only read ahead by one token. Close must
+// be called when the MergeEnumerator is abandoned before the end of
+// input, so that the goroutines exit.
+func Merge(es ...Enumerator) *MergeEnumerator {
+	e := &MergeEnumerator{
+		items: make(chan mergeItem),
+		done:  make(chan struct{}),
+		left:  len(es),
+		index: -1,
+	}
+	for i, src := range es {
+		go e.read(i, src)
+	}
+	return e
+}
+
+// read sends the tokens of src to e.items until its end or until e is
+// closed.
+func (e *MergeEnumerator) read(origin int, src Enumerator) {
+	err := Run(src, mergeI{e, origin})
+	if err == errMergeClosed {
+		return
+	}
+	select {
+	case e.items <- mergeItem{Origin: origin, End: true, Err: err}:
+	case <-e.done:
+	}
+}
+
+func (e *MergeEnumerator) Step(it Iteratee) (Iteratee, error) {
+	for !e.have {
+		if e.left == 0 {
+			return nil, it.Final()
+		}
+		item := <-e.items
+		if item.End {
+			if item.Err != nil {
+				return nil, MergeErr{item.Origin, item.Err}
+			}
+			e.left--
+			continue
+		}
+		e.cur, e.have = item, true
+		e.index++
+	}
+	next, read, err := it.Next(e.cur.Token)
+	if err != nil {
+		return nil, WrapTokenErrorAt(e.cur.Token, -1, e.index, err)
+	}
+	if read {
+		e.have = false
+	}
+	return next, nil
+}
+
+// Origin returns the index in the arguments of Merge of the source of
+// the current token, i.e. the last one presented to the Iteratee.
+func (e *MergeEnumerator) Origin() int {
+	return e.cur.Origin
+}
+
+// Close stops reading the sources. Sources that are blocked inside their
+// own Step (e.g. on a read) only stop once it returns.
+func (e *MergeEnumerator) Close() error {
+	e.once.Do(func() { close(e.done) })
+	return nil
+}
+
+// errMergeClosed stops a source of a closed MergeEnumerator.
+var errMergeClosed = errors.New("stream: merge closed")
+
+// mergeI sends each token of source Origin to M.
+type mergeI struct {
+	M      *MergeEnumerator
+	Origin int
+}
+
+func (it mergeI) Final() error { return nil }
+func (it mergeI) Next(token []byte) (Iteratee, bool, error) {
+	select {
+	case it.M.items <- mergeItem{Origin: it.Origin, Token: append([]byte(nil), token...)}:
+		return it, true, nil
+	case <-it.M.done:
+		return nil, false, errMergeClosed
+	}
+}
+
+// MergeErr reports an error from a source of a MergeEnumerator.
+type MergeErr struct {
+	Origin int // the index of the source.
+	Err    error
+}
+
+func (e MergeErr) Error() string { return fmt.Sprintf("source %d: %v", e.Origin, e.Err) }
+func (e MergeErr) Unwrap() error { return e.Err }
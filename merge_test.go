@@ -0,0 +1,74 @@
+package stream
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMerge(t *testing.T) {
+	a, b := make(chan []byte), make(chan []byte)
+	e := Merge(NewChanEnumerator(a), NewChanEnumerator(b))
+	defer e.Close()
+	go func() {
+		a <- []byte("a1")
+		b <- []byte("b1")
+		b <- []byte("b2")
+		a <- []byte("a2")
+		close(a)
+		close(b)
+	}()
+	var got []string
+	var origins []int
+	record := OnToken(Star(Skip), func(token []byte) {
+		got = append(got, string(token))
+		origins = append(origins, e.Origin())
+	})
+	if err := Run(e, record); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// Sources are read concurrently, so only the order within each
+	// source is known.
+	bySource := make([]string, 2)
+	for i, o := range origins {
+		if want := int(got[i][0] - 'a'); o != want {
+			t.Errorf("token %q: expect origin %d; got %d", got[i], want, o)
+		}
+		bySource[o] += got[i]
+	}
+	if bySource[0] != "a1a2" || bySource[1] != "b1b2" {
+		t.Errorf("expect tokens in order within each source; got %q", got)
+	}
+
+	// Errors of the Iteratee and of the sources.
+	err := Run(Merge(Words(strings.NewReader("a b"))), Seq(Match("a"), Match("c")))
+	if te, ok := err.(TokenErr); !ok || te.Token != "b" || te.Index != 1 {
+		t.Errorf("expect error on token %q; got %v", "b", err)
+	}
+	boom := errors.New("boom")
+	err = Run(Merge(Words(strings.NewReader("a")), Words(io.MultiReader(strings.NewReader("b "), iotest.ErrReader(boom)))), Star(Skip))
+	var me MergeErr
+	if !errors.As(err, &me) || me.Origin != 1 || !errors.Is(err, boom) {
+		t.Errorf("expect error from source 1; got %v", err)
+	}
+
+	// No source.
+	if err := Run(Merge(), EOF); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMergeClose(t *testing.T) {
+	ch := make(chan []byte, 1)
+	ch <- []byte("a")
+	e := Merge(NewChanEnumerator(ch))
+	if err := Run(e, Match("a")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	e.Close()
+	e.Close()
+	ch <- []byte("b") // taken by the source, which then stops.
+	close(ch)
+}
@@ -0,0 +1,52 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// MessageReader reads whole messages, e.g. from a WebSocket connection.
+// It returns io.EOF after the last message; an adapter should map a
+// normal closure of the connection to io.EOF, e.g. for gorilla/websocket:
+//
+//	func (r wsReader) ReadMessage() ([]byte, error) {
+//		_, p, err := r.Conn.ReadMessage()
+//		if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+//			err = io.EOF
+//		}
+//		return p, err
+//	}
+type MessageReader interface {
+	ReadMessage() ([]byte, error)
+}
+
+// NewMessageEnumerator creates an Enumerator over the messages from r,
+// one token per message. Offsets in errors are those in the
+// concatenation of the messages.
+func NewMessageEnumerator(r MessageReader) Enumerator {
+	return &messageEnumerator{r: r}
+}
+
+// NewSplitMessageEnumerator creates an Enumerator over the messages from
+// r, each of which is split by split. A token never spans messages.
+// Positions in errors are those within the message of the token.
+func NewSplitMessageEnumerator(r MessageReader, split bufio.SplitFunc) Enumerator {
+	return &concatEnumerator{more: func() (Enumerator, error) {
+		msg, err := r.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		return NewScanEnumeratorWith(bytes.NewReader(msg), split), nil
+	}}
+}
+
+// messageEnumerator implements NewMessageEnumerator().
+type messageEnumerator struct {
+	r    MessageReader
+	slot tokenSlot
+}
+
+func (e *messageEnumerator) Step(it Iteratee) (Iteratee, error) {
+	next, _, err := e.slot.step(it, e.r.ReadMessage)
+	return next, err
+}
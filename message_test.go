@@ -0,0 +1,55 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"testing"
+)
+
+// messages is a MessageReader over a fixed list of messages, followed
+// by Err.
+type messages struct {
+	List []string
+	Err  error
+}
+
+func (m *messages) ReadMessage() ([]byte, error) {
+	if len(m.List) == 0 {
+		return nil, m.Err
+	}
+	msg := m.List[0]
+	m.List = m.List[1:]
+	return []byte(msg), nil
+}
+
+func TestMessageEnumerator(t *testing.T) {
+	errClosed := errors.New("closed")
+	for _, i := range []struct {
+		Msgs  []string
+		Split bool
+		End   error
+		Err   string
+	}{
+		{[]string{"(", "x y", ")"}, false, io.EOF, ""},
+		{[]string{"(", "z", ")"}, false, io.EOF, `token "z" at offset 1 (token #1): expect ")"`},
+		{[]string{"(", "x y"}, false, errClosed, "closed"},
+		{[]string{"( x", "y )"}, true, io.EOF, ""},
+		{[]string{"( x", "", "y", ")"}, true, io.EOF, ""},
+		{[]string{"( x", "z )"}, true, io.EOF, `token "z" at offset 0 (token #0): expect ")"`},
+		{[]string{"( x"}, true, errClosed, "closed"},
+	} {
+		var e Enumerator
+		if i.Split {
+			e = NewSplitMessageEnumerator(&messages{i.Msgs, i.End}, bufio.ScanWords)
+		} else {
+			e = NewMessageEnumerator(&messages{i.Msgs, i.End})
+		}
+		err := Run(e, Seq(Match("("), Star(MatchOneOf("x y", "x", "y")), Match(")"), EOF))
+		if i.Err == "" && err != nil {
+			t.Errorf("messages %q: unexpected error %v", i.Msgs, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("messages %q: expect error %q; got %v", i.Msgs, i.Err, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package stream
+
+import "sync/atomic"
+
+// Metrics is an Enumerator that counts what passes through another
+// Enumerator. Its counters may be read by Stats from any goroutine
+// while it is running. A zero Metrics only counts through Wrap.
+type Metrics struct {
+	e                            Enumerator
+	steps, tokens, bytes, errors atomic.Int64
+}
+
+// Stats is a snapshot of the counters of a Metrics.
+type Stats struct {
+	Steps  int64 // calls of Step.
+	Tokens int64 // consumed tokens.
+	Bytes  int64 // total size of the consumed tokens.
+	Errors int64 // steps that failed.
+}
+
+// NewMetrics creates a Metrics counting for e.
+func NewMetrics(e Enumerator) *Metrics {
+	return &Metrics{e: e}
+}
+
+func (m *Metrics) Step(it Iteratee) (Iteratee, error) {
+	return m.step(m.e, it)
+}
+
+// Wrap is an EnumeratorMiddleware that counts what passes through e in
+// m, in addition to anything else counted in m.
+func (m *Metrics) Wrap(e Enumerator) Enumerator {
+	return metricsEnumerator{m, e}
+}
+
+// metricsEnumerator implements Metrics.Wrap().
+type metricsEnumerator struct {
+	m *Metrics
+	e Enumerator
+}
+
+func (e metricsEnumerator) Step(it Iteratee) (Iteratee, error) {
+	return e.m.step(e.e, it)
+}
+
+// step steps e while counting in m.
+func (m *Metrics) step(e Enumerator, it Iteratee) (Iteratee, error) {
+	m.steps.Add(1)
+	next, err := e.Step(metricsI{it, m})
+	if err != nil {
+		m.errors.Add(1)
+	}
+	return next, err
+}
+
+// Stats returns the current counters.
+func (m *Metrics) Stats() Stats {
+	return Stats{m.steps.Load(), m.tokens.Load(), m.bytes.Load(), m.errors.Load()}
+}
+
+// metricsI counts the tokens consumed by A.
+type metricsI struct {
+	A Iteratee
+	M *Metrics
+}
+
+func (it metricsI) Final() error { return it.A.Final() }
+func (it metricsI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err == nil && read {
+		it.M.tokens.Add(1)
+		it.M.bytes.Add(int64(len(token)))
+	}
+	return next, read, err
+}
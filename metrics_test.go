@@ -0,0 +1,24 @@
+package stream
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	for _, i := range []struct {
+		Input string
+		Stats Stats
+	}{
+		{"( xx x )", Stats{Steps: 6, Tokens: 4, Bytes: 5}},
+		{"( x y", Stats{Steps: 4, Tokens: 2, Bytes: 2, Errors: 1}},
+		{"", Stats{Steps: 1, Errors: 1}},
+	} {
+		m := NewMetrics(NewScanEnumeratorWith(strings.NewReader(i.Input), bufio.ScanWords))
+		Run(m, Seq(Match("("), Star(MatchOneOf("x", "xx")), Match(")"), EOF))
+		if s := m.Stats(); s != i.Stats {
+			t.Errorf("input %q: expect %+v; got %+v", i.Input, i.Stats, s)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package stream
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// EnumeratorMiddleware wraps an Enumerator to add a cross-cutting
+// concern, such as logging, accounting or limits, without changing the
+// tokens. The wrapping Enumerator passes every step through to the
+// wrapped one, typically with the Iteratee wrapped to observe it.
+type EnumeratorMiddleware func(Enumerator) Enumerator
+
+// Chain composes ms into one EnumeratorMiddleware, the first of which is
+// the outermost, e.g.
+//
+//	e = Chain(WithinContext(ctx), m.Wrap, TeeTo(w, nl))(Lines(in))
+//
+// tees the lines that are consumed, counts them in m, and stops when ctx
+// is done.
+func Chain(ms ...EnumeratorMiddleware) EnumeratorMiddleware {
+	return func(e Enumerator) Enumerator {
+		for i := len(ms) - 1; i >= 0; i-- {
+			e = ms[i](e)
+		}
+		return e
+	}
+}
+
+// TeeTo is the EnumeratorMiddleware of Tee.
+func TeeTo(w io.Writer, sep []byte) EnumeratorMiddleware {
+	return func(e Enumerator) Enumerator { return Tee(e, w, sep) }
+}
+
+// TimeoutAfter is the EnumeratorMiddleware of Timeout.
+func TimeoutAfter(d time.Duration, final bool) EnumeratorMiddleware {
+	return func(e Enumerator) Enumerator { return Timeout(e, d, final) }
+}
+
+// WithinContext is the EnumeratorMiddleware of WithContext.
+func WithinContext(ctx context.Context) EnumeratorMiddleware {
+	return func(e Enumerator) Enumerator { return WithContext(ctx, e) }
+}
+
+// LimitInput is an EnumeratorMiddleware that fails with ErrTokenLimit
+// when more than max tokens are consumed in total, like LimitTokens
+// applied to the whole input.
+func LimitInput(max int) EnumeratorMiddleware {
+	return func(e Enumerator) Enumerator { return &limitEnumerator{e: e, max: max} }
+}
+
+// limitEnumerator implements LimitInput(). n is the number of consumed
+// tokens.
+type limitEnumerator struct {
+	e      Enumerator
+	n, max int
+}
+
+func (e *limitEnumerator) Step(it Iteratee) (Iteratee, error) {
+	return e.e.Step(limitInputI{it, e})
+}
+
+// limitInputI counts the tokens consumed by A.
+type limitInputI struct {
+	A Iteratee
+	E *limitEnumerator
+}
+
+func (it limitInputI) Final() error { return it.A.Final() }
+func (it limitInputI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil || !read {
+		return next, read, err
+	}
+	if it.E.n == it.E.max {
+		return nil, false, ErrTokenLimit(it.E.max)
+	}
+	it.E.n++
+	return next, read, nil
+}
@@ -0,0 +1,53 @@
+package stream
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	var order []string
+	mark := func(name string) EnumeratorMiddleware {
+		return func(e Enumerator) Enumerator {
+			order = append(order, name)
+			return e
+		}
+	}
+	Chain(mark("a"), mark("b"), mark("c"))(Words(strings.NewReader("")))
+	if got := strings.Join(order, ""); got != "cba" {
+		t.Errorf("expect the first middleware to wrap last; got %q", got)
+	}
+
+	var (
+		buf bytes.Buffer
+		m   Metrics
+	)
+	e := Chain(m.Wrap, TeeTo(&buf, []byte(",")), LimitInput(3))(Words(strings.NewReader("( x )")))
+	if err := Run(e, Seq(Match("("), Star(Match("x")), Match(")"), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if buf.String() != "(,x,)," {
+		t.Errorf("expect %q; got %q", "(,x,),", buf.String())
+	}
+	if s := m.Stats(); s.Tokens != 3 || s.Bytes != 3 {
+		t.Errorf("expect 3 tokens; got %+v", s)
+	}
+}
+
+func TestLimitInput(t *testing.T) {
+	for _, i := range []struct {
+		Input string
+		Err   string
+	}{
+		{"x x", ""},
+		{"x x x", `token "x" at offset 4 (token #2): more than 2 tokens`},
+	} {
+		err := Run(LimitInput(2)(Words(strings.NewReader(i.Input))), Seq(Star(Match("x")), EOF))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
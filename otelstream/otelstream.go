@@ -0,0 +1,62 @@
+// Package otelstream records runs of stream Enumerators as OpenTelemetry
+// spans.
+package otelstream
+
+import (
+	"context"
+
+	"github.com/kho/stream"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Enumerator wraps e to record a run as a span named name, started from
+// ctx with tracer at the first step and ended at the last one, i.e. when
+// a step fails or returns a nil Iteratee. The span has the counters of
+// stream.Stats as attributes and records the error, if any. The span of
+// an abandoned run is never ended.
+func Enumerator(ctx context.Context, tracer trace.Tracer, name string, e stream.Enumerator) stream.Enumerator {
+	return &tracedEnumerator{ctx: ctx, tracer: tracer, name: name, m: stream.NewMetrics(e)}
+}
+
+// Run is like stream.Run but records the run as a span (see
+// Enumerator).
+func Run(ctx context.Context, tracer trace.Tracer, name string, e stream.Enumerator, it stream.Iteratee) error {
+	return stream.Run(Enumerator(ctx, tracer, name, e), it)
+}
+
+// tracedEnumerator implements Enumerator(). span is nil until the first
+// step.
+type tracedEnumerator struct {
+	ctx    context.Context
+	tracer trace.Tracer
+	name   string
+	m      *stream.Metrics
+	span   trace.Span
+}
+
+func (e *tracedEnumerator) Step(it stream.Iteratee) (stream.Iteratee, error) {
+	if e.span == nil {
+		_, e.span = e.tracer.Start(e.ctx, e.name)
+	}
+	next, err := e.m.Step(it)
+	if err != nil || next == nil {
+		e.end(err)
+	}
+	return next, err
+}
+
+func (e *tracedEnumerator) end(err error) {
+	s := e.m.Stats()
+	e.span.SetAttributes(
+		attribute.Int64("stream.steps", s.Steps),
+		attribute.Int64("stream.tokens", s.Tokens),
+		attribute.Int64("stream.bytes", s.Bytes),
+	)
+	if err != nil {
+		e.span.RecordError(err)
+		e.span.SetStatus(codes.Error, err.Error())
+	}
+	e.span.End()
+}
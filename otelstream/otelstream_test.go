@@ -0,0 +1,47 @@
+package otelstream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kho/stream"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRun(t *testing.T) {
+	for _, i := range []struct {
+		Input  []string
+		Tokens int64
+		Status codes.Code
+		Events int
+	}{
+		{[]string{"(", "x", ")"}, 3, codes.Unset, 0},
+		{[]string{"(", "y"}, 1, codes.Error, 1},
+	} {
+		rec := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)).Tracer("test")
+		list := stream.Seq(stream.Match("("), stream.Star(stream.Match("x")), stream.Match(")"), stream.EOF)
+		Run(context.Background(), tracer, "parse", stream.NewStringsEnumerator(i.Input), list)
+
+		spans := rec.Ended()
+		if len(spans) != 1 {
+			t.Fatalf("input %q: expect 1 span; got %d", i.Input, len(spans))
+		}
+		s := spans[0]
+		if s.Name() != "parse" || s.Status().Code != i.Status || len(s.Events()) != i.Events {
+			t.Errorf("input %q: unexpected span %q with status %v and events %v", i.Input, s.Name(), s.Status(), s.Events())
+		}
+		var tokens int64 = -1
+		for _, a := range s.Attributes() {
+			if a.Key == attribute.Key("stream.tokens") {
+				tokens = a.Value.AsInt64()
+			}
+		}
+		if tokens != i.Tokens {
+			t.Errorf("input %q: expect %d tokens; got %d", i.Input, i.Tokens, tokens)
+		}
+	}
+}
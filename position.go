@@ -0,0 +1,68 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// PositionEnumerator is a ScanEnumerator that also tracks the line and
+// column of each token, and reports errors on a token as a PosErr.
+type PositionEnumerator struct {
+	*ScanEnumerator
+	// line and col locate the current token; endLine and endCol locate
+	// the first byte the split function has not advanced past.
+	line, col, endLine, endCol int
+}
+
+// NewPositionEnumerator creates a PositionEnumerator reading from in
+// and splitting it with split.
+func NewPositionEnumerator(in io.Reader, split bufio.SplitFunc) *PositionEnumerator {
+	e := &PositionEnumerator{endLine: 1, endCol: 1}
+	e.ScanEnumerator = NewScanEnumeratorWith(in, e.track(split))
+	return e
+}
+
+func (e *PositionEnumerator) Step(it Iteratee) (Iteratee, error) {
+	next, err := e.ScanEnumerator.Step(it)
+	if te, ok := err.(TokenErr); ok {
+		err = PosErr{e.line, e.col, te.Offset, te.Token, te.Err}
+	}
+	return next, err
+}
+
+// track wraps split to keep the line and column up to date.
+func (e *PositionEnumerator) track(split bufio.SplitFunc) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := split(data, atEOF)
+		if token != nil {
+			e.line, e.col = advancePos(e.endLine, e.endCol, data[:tokenStart(data, token)])
+		}
+		e.endLine, e.endCol = advancePos(e.endLine, e.endCol, data[:advance])
+		return advance, token, err
+	}
+}
+
+// advancePos returns the line and column after data starting from line
+// and col.
+func advancePos(line, col int, data []byte) (int, int) {
+	if n := bytes.Count(data, []byte("\n")); n > 0 {
+		return line + n, len(data) - bytes.LastIndexByte(data, '\n')
+	}
+	return line, col + len(data)
+}
+
+// PosErr wraps an error with the input token and its position.
+type PosErr struct {
+	Line, Col int // 1-based line and byte column of the token.
+	Offset    int // byte offset of the token in the input.
+	Token     string
+	Err       error
+}
+
+func (e PosErr) Error() string {
+	return fmt.Sprintf("%d:%d: token %q: %v", e.Line, e.Col, e.Token, e.Err)
+}
+
+func (e PosErr) Unwrap() error { return e.Err }
@@ -0,0 +1,34 @@
+package stream
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestPositionEnumerator(t *testing.T) {
+	list := Seq(Match("("), Star(Match("x")), Match(")"), EOF)
+	for _, i := range []struct {
+		Input, Err string
+		Offset     int
+	}{
+		{"( x\n  x )", "", 0},
+		{"( x\n  y )", `2:3: token "y": expect ")"`, 6},
+		{"(\n\n\tx y", `3:4: token "y": expect ")"`, 6},
+		{"y", `1:1: token "y": expect "("`, 0},
+	} {
+		err := Run(NewPositionEnumerator(strings.NewReader(i.Input), bufio.ScanWords), list)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		} else if pe, ok := err.(PosErr); ok && pe.Offset != i.Offset {
+			t.Errorf("input %q: expect offset %d; got %d", i.Input, i.Offset, pe.Offset)
+		}
+	}
+
+	// The end of input is not located.
+	if err := Run(NewPositionEnumerator(strings.NewReader("( x"), bufio.ScanWords), list); err == nil || err.Error() != `expect ")"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package stream
+
+import "errors"
+
+// PushbackEnumerator is an Enumerator that can put consumed tokens back
+// into its input: a token put back is presented again before the
+// current token. It remembers a limited number of the latest consumed
+// tokens for UnreadToken.
+type PushbackEnumerator struct {
+	e Enumerator
+	// pending holds the tokens put back, which are presented before
+	// any new token from e.
+	pending [][]byte
+	// history holds copies of the latest consumed tokens, at most max
+	// of them, latest last.
+	history [][]byte
+	max     int
+	// pos is the number of tokens consumed so far, less those put back.
+	// It is also the position of the current token.
+	pos int
+}
+
+// NewPushbackEnumerator creates a PushbackEnumerator reading from e
+// that allows putting back up to k tokens.
+func NewPushbackEnumerator(e Enumerator, k int) *PushbackEnumerator {
+	return &PushbackEnumerator{e: e, max: k}
+}
+
+func (p *PushbackEnumerator) Step(it Iteratee) (Iteratee, error) {
+	if len(p.pending) == 0 {
+		return p.e.Step(pushbackI{it, p})
+	}
+	token, rest := p.pending[0], p.pending[1:]
+	p.pending = rest
+	next, read, err := it.Next(token)
+	if err == nil && read {
+		p.consume(token)
+	} else {
+		// Keep token after any token put back during Next.
+		n := len(p.pending) - len(rest)
+		pending := make([][]byte, 0, len(p.pending)+1)
+		pending = append(pending, p.pending[:n]...)
+		pending = append(pending, token)
+		p.pending = append(pending, p.pending[n:]...)
+	}
+	return next, WrapTokenError(token, err)
+}
+
+// UnreadToken puts back the latest consumed token that has not been put
+// back. It may be called several times, e.g. from Iteratee.Next, to put
+// back up to k tokens. It fails with ErrNoUnread when the history is
+// exhausted.
+func (p *PushbackEnumerator) UnreadToken() error {
+	if len(p.history) == 0 {
+		return ErrNoUnread
+	}
+	p.unread(p.history[len(p.history)-1:])
+	return nil
+}
+
+// ErrNoUnread reports that no token can be put back.
+var ErrNoUnread = errors.New("no consumed token to unread")
+
+// consume records that token is consumed. token must be a copy.
+func (p *PushbackEnumerator) consume(token []byte) {
+	p.pos++
+	if p.max <= 0 {
+		return
+	}
+	if len(p.history) == p.max {
+		p.history = append(p.history[:0:0], p.history[1:]...)
+	}
+	p.history = append(p.history, token)
+}
+
+// unread puts tokens back so that they are presented next, before the
+// current token. They are taken off the history when they are in it.
+func (p *PushbackEnumerator) unread(tokens [][]byte) {
+	p.pending = append(append([][]byte(nil), tokens...), p.pending...)
+	p.pos -= len(tokens)
+	if n := len(p.history) - len(tokens); n > 0 {
+		p.history = p.history[:n]
+	} else {
+		p.history = nil
+	}
+}
+
+// pushbackI wraps an Iteratee to record the tokens it consumes from the
+// underlying Enumerator.
+type pushbackI struct {
+	A Iteratee
+	P *PushbackEnumerator
+}
+
+func (it pushbackI) Final() error { return it.A.Final() }
+func (it pushbackI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err == nil && read {
+		if it.P.max > 0 {
+			token = append([]byte(nil), token...)
+		}
+		it.P.consume(token)
+	}
+	return next, read, err
+}
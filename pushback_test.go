@@ -0,0 +1,54 @@
+package stream
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+// unreadI puts back N tokens of P before the current token.
+type unreadI struct {
+	P *PushbackEnumerator
+	N int
+}
+
+func (it unreadI) Final() error { return ErrExpect("a token") }
+func (it unreadI) Next(token []byte) (Iteratee, bool, error) {
+	for i := 0; i < it.N; i++ {
+		if err := it.P.UnreadToken(); err != nil {
+			return nil, false, err
+		}
+	}
+	return nil, false, nil
+}
+
+func TestPushbackEnumerator(t *testing.T) {
+	for _, i := range []struct {
+		Input string
+		K     int
+		It    func(p *PushbackEnumerator) Iteratee
+		Err   error
+	}{
+		{"a b c", 2, func(p *PushbackEnumerator) Iteratee {
+			return Seq(Match("a"), Match("b"), unreadI{p, 2}, Match("a"), Match("b"), Match("c"), EOF)
+		}, nil},
+		{"a b c", 1, func(p *PushbackEnumerator) Iteratee {
+			return Seq(Match("a"), Match("b"), unreadI{p, 1}, Match("b"), unreadI{p, 1}, Match("b"), Match("c"), EOF)
+		}, nil},
+		{"a b c", 1, func(p *PushbackEnumerator) Iteratee {
+			return Seq(Match("a"), Match("b"), unreadI{p, 2}, Match("a"), EOF)
+		}, ErrNoUnread},
+		{"a b c", 0, func(p *PushbackEnumerator) Iteratee {
+			return Seq(Match("a"), unreadI{p, 1})
+		}, ErrNoUnread},
+	} {
+		p := NewPushbackEnumerator(NewScanEnumeratorWith(strings.NewReader(i.Input), bufio.ScanWords), i.K)
+		err := Run(p, i.It(p))
+		if te, ok := err.(TokenErr); ok {
+			err = te.Err
+		}
+		if err != i.Err {
+			t.Errorf("input %q: expect error %v; got %v", i.Input, i.Err, err)
+		}
+	}
+}
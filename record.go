@@ -0,0 +1,44 @@
+package stream
+
+// Recorder is an Enumerator that records copies of the tokens from
+// another Enumerator as they are consumed, so that a failing input can
+// be replayed later (see Replay) without the original source.
+type Recorder struct {
+	e      Enumerator
+	tokens [][]byte
+}
+
+// Record creates a Recorder reading from e.
+func Record(e Enumerator) *Recorder {
+	return &Recorder{e: e}
+}
+
+func (r *Recorder) Step(it Iteratee) (Iteratee, error) {
+	return r.e.Step(recordI{it, r})
+}
+
+// Tokens returns the tokens recorded so far: those consumed and the
+// token on which the Iteratee failed, if any.
+func (r *Recorder) Tokens() [][]byte {
+	return r.tokens
+}
+
+// Replay returns an Enumerator over the recorded tokens.
+func (r *Recorder) Replay() *SliceEnumerator {
+	return NewSliceEnumerator(r.tokens)
+}
+
+// recordI records the tokens consumed by A.
+type recordI struct {
+	A Iteratee
+	R *Recorder
+}
+
+func (it recordI) Final() error { return it.A.Final() }
+func (it recordI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil || read {
+		it.R.tokens = append(it.R.tokens, append([]byte(nil), token...))
+	}
+	return next, read, err
+}
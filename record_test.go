@@ -0,0 +1,41 @@
+package stream
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	list := Seq(Match("("), Star(Match("x")), Match(")"), EOF)
+	for _, i := range []struct {
+		Input  string
+		Tokens []string
+		Err    string
+	}{
+		{"( x x )", []string{"(", "x", "x", ")"}, ""},
+		{"( x y )", []string{"(", "x", "y"}, `expect ")"`},
+		{"( x", []string{"(", "x"}, `expect ")"`},
+	} {
+		r := Record(NewScanEnumeratorWith(strings.NewReader(i.Input), bufio.ScanWords))
+		err := Run(r, list)
+		var tokens []string
+		for _, t := range r.Tokens() {
+			tokens = append(tokens, string(t))
+		}
+		if !reflect.DeepEqual(tokens, i.Tokens) {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, tokens)
+		}
+		// The replay fails in the same way.
+		replayed := Run(r.Replay(), list)
+		if i.Err == "" && (err != nil || replayed != nil) {
+			t.Errorf("input %q: unexpected error %v or %v in replay", i.Input, err, replayed)
+		} else if te, ok := replayed.(TokenErr); ok {
+			replayed = te.Err
+		}
+		if i.Err != "" && (replayed == nil || replayed.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q in replay; got %v", i.Input, i.Err, replayed)
+		}
+	}
+}
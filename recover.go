@@ -0,0 +1,49 @@
+package stream
+
+// Sync discards tokens until one of tokens, which is left for the next
+// Iteratee. It is meant to resynchronize after an error (see Recover),
+// e.g. Seq(Sync(";"), Match(";")) skips the rest of a statement.
+func Sync(tokens ...string) Iteratee {
+	set := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		set[t] = true
+	}
+	return SkipWhile(func(token []byte) bool { return !set[string(token)] })
+}
+
+// Recover runs it, and when it fails, continues with handler(err)
+// instead. The token it failed on is not consumed, so it is the first
+// one seen by the Iteratee returned by handler, which may be nil to
+// finish immediately.
+func Recover(it Iteratee, handler func(err error) Iteratee) Iteratee {
+	return recoverI{it, handler}
+}
+
+// recoverI implements Recover().
+type recoverI struct {
+	A       Iteratee
+	Handler func(error) Iteratee
+}
+
+func (it recoverI) Final() error {
+	err := it.A.Final()
+	if err == nil {
+		return nil
+	}
+	if h := it.Handler(err); h != nil {
+		return h.Final()
+	}
+	return nil
+}
+
+func (it recoverI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		return it.Handler(err), false, nil
+	}
+	if next == nil {
+		return nil, read, nil
+	}
+	it.A = next
+	return it, read, nil
+}
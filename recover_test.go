@@ -0,0 +1,55 @@
+package stream
+
+import "testing"
+
+func TestSync(t *testing.T) {
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"a b ; c", true},
+		{"; c", true},
+		{"a b } c", true},
+		{"a b c", false},
+	} {
+		err := runWords(i.Input, Seq(Sync(";", "}"), MatchOneOf(";", "}"), Match("c"), EOF))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var errs []error
+	stmt := Recover(Seq(Skip, Match("="), Skip, Match(";")), func(err error) Iteratee {
+		errs = append(errs, err)
+		return Seq(Sync(";"), Match(";"))
+	})
+	for _, i := range []struct {
+		Input string
+		Errs  int
+		OK    bool
+	}{
+		{"a = 1 ; b = 2 ;", 0, true},
+		{"a = 1 ; b 2 ; c = 3 ;", 1, true},
+		{"a ; b ; c = 3 ;", 2, true},
+		{"a = 1 ; b", 1, false},
+	} {
+		errs = nil
+		err := runWords(i.Input, Seq(Star(stmt), EOF))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+		if len(errs) != i.Errs {
+			t.Errorf("input %q: expect %d recovered errors; got %v", i.Input, i.Errs, errs)
+		}
+	}
+
+	if err := runWords("a", Seq(Recover(Match("b"), func(error) Iteratee { return nil }), Match("a"), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package stream
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ChoiceRegistry holds named alternatives grouped by kind, so that
+// packages can contribute alternatives (e.g. record types) at init
+// time. It is safe for concurrent use.
+type ChoiceRegistry struct {
+	mu    sync.RWMutex
+	kinds map[string][]namedChoice
+}
+
+type namedChoice struct {
+	name string
+	it   Iteratee
+}
+
+// NewChoiceRegistry creates an empty ChoiceRegistry.
+func NewChoiceRegistry() *ChoiceRegistry {
+	return &ChoiceRegistry{kinds: map[string][]namedChoice{}}
+}
+
+// Register adds it as the alternative called name of kind. It panics
+// if name is already registered for kind.
+func (r *ChoiceRegistry) Register(kind, name string, it Iteratee) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.kinds[kind] {
+		if c.name == name {
+			panic(fmt.Sprintf("stream: %s %q registered twice", kind, name))
+		}
+	}
+	r.kinds[kind] = append(r.kinds[kind], namedChoice{name, it})
+}
+
+// Names returns the names registered for kind in registration order.
+func (r *ChoiceRegistry) Names(kind string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var names []string
+	for _, c := range r.kinds[kind] {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// Registered returns an Iteratee that runs the alternatives of kind as
+// in Or. The alternatives are looked up each time it starts, so it may
+// be created before all of them are registered. Errors are labeled with
+// kind.
+func (r *ChoiceRegistry) Registered(kind string) Iteratee {
+	return registeredI{r, kind}
+}
+
+func (r *ChoiceRegistry) choice(kind string) Iteratee {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cs := r.kinds[kind]
+	its := make([]Iteratee, len(cs))
+	for i, c := range cs {
+		its[i] = c.it
+	}
+	return Label(Or(its...), kind)
+}
+
+// registeredI implements ChoiceRegistry.Registered().
+type registeredI struct {
+	R    *ChoiceRegistry
+	Kind string
+}
+
+func (it registeredI) Final() error { return it.R.choice(it.Kind).Final() }
+func (it registeredI) Next(token []byte) (Iteratee, bool, error) {
+	return it.R.choice(it.Kind).Next(token)
+}
+
+// DefaultChoices is the ChoiceRegistry used by Register and Registered.
+var DefaultChoices = NewChoiceRegistry()
+
+// Register adds it to DefaultChoices (see ChoiceRegistry.Register).
+func Register(kind, name string, it Iteratee) {
+	DefaultChoices.Register(kind, name, it)
+}
+
+// Registered runs the alternatives of kind in DefaultChoices (see
+// ChoiceRegistry.Registered).
+func Registered(kind string) Iteratee {
+	return DefaultChoices.Registered(kind)
+}
@@ -0,0 +1,43 @@
+package stream
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChoiceRegistry(t *testing.T) {
+	r := NewChoiceRegistry()
+	records := Seq(Star(r.Registered("record")), EOF)
+	r.Register("record", "user", Seq(Match("user"), Skip))
+	r.Register("record", "group", Seq(Match("group"), Skip, Skip))
+	if names := r.Names("record"); !reflect.DeepEqual(names, []string{"user", "group"}) {
+		t.Errorf("unexpected names %q", names)
+	}
+	for _, i := range []struct {
+		Input, Err string
+	}{
+		{"user a group b c user d", ""},
+		{"user a host b", `token "host" at offset 7 (token #2): expect <eof>`},
+		{"group a", `in record: expect a token`},
+	} {
+		err := runWords(i.Input, records)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+
+	// Alternatives registered later are picked up.
+	r.Register("record", "host", Seq(Match("host"), Skip))
+	if err := runWords("user a host b", records); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expect panic")
+		}
+	}()
+	r.Register("record", "user", Skip)
+}
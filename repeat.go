@@ -0,0 +1,181 @@
+package stream
+
+import "fmt"
+
+// Repeat runs it exactly n times in sequence.
+func Repeat(it Iteratee, n int) Iteratee {
+	return RepeatRange(it, n, n)
+}
+
+// RepeatRange runs it at least min and at most max times in sequence;
+// a negative max means no upper bound. Like Star, it stops repeating
+// when a repetition fails on its first token, but that is an error
+// until min repetitions have been completed. It panics if max is not
+// negative and smaller than min.
+func RepeatRange(it Iteratee, min, max int) Iteratee {
+	if max >= 0 && min > max {
+		panic(fmt.Sprintf("stream: invalid repetition range [%d, %d]", min, max))
+	}
+	return repeatI{it, 0, min, max}
+}
+
+// Plus repeats an Iteratee one or more times. It is StarMin(it, 1), so
+// unlike Seq(it, Star(it)), it follows the transitions of it that do not
+// consume the token, returns the error of a repetition that fails after
+// consuming tokens, and reports a missing first repetition as a
+// RepeatErr.
+func Plus(it Iteratee) Iteratee {
+	return RepeatRange(it, 1, -1)
+}
+
+// StarStrict repeats it zero or more times. Unlike Star, which stops
+// when the first Next of a repetition fails, StarStrict follows the
+// transitions of it that do not consume the token and stops only when
+// it fails before consuming anything; an error after a repetition has
+// consumed tokens is returned as is. It also stops on a repetition that
+// matches nothing.
+func StarStrict(it Iteratee) Iteratee {
+	return RepeatRange(it, 0, -1)
+}
+
+// StarMin is like StarStrict but requires at least n repetitions.
+func StarMin(it Iteratee, n int) Iteratee {
+	return RepeatRange(it, n, -1)
+}
+
+// SepBy matches zero or more item separated by sep.
+func SepBy(item, sep Iteratee) Iteratee {
+	return RepeatRange(SepBy1(item, sep), 0, 1)
+}
+
+// SepBy1 matches one or more item separated by sep.
+func SepBy1(item, sep Iteratee) Iteratee {
+	return Seq(item, Star(Seq(sep, item)))
+}
+
+// SepEndBy is like SepBy but allows a trailing sep.
+func SepEndBy(item, sep Iteratee) Iteratee {
+	return RepeatRange(SepEndBy1(item, sep), 0, 1)
+}
+
+// SepEndBy1 is like SepBy1 but allows a trailing sep.
+func SepEndBy1(item, sep Iteratee) Iteratee {
+	return Seq(item, sepEndI{item, sep})
+}
+
+// sepEndI matches what may follow an item in SepEndBy1(): an optional
+// sep, which may be followed by another item and so on.
+type sepEndI struct {
+	Item, Sep Iteratee
+}
+
+func (it sepEndI) Final() error { return nil }
+func (it sepEndI) Next(token []byte) (Iteratee, bool, error) {
+	return RepeatRange(Seq(it.Sep, RepeatRange(Seq(it.Item, it), 0, 1)), 0, 1).Next(token)
+}
+
+// EndBy matches zero or more item, each followed by term. Unlike
+// SepEndBy, the last term is required.
+func EndBy(item, term Iteratee) Iteratee {
+	return Star(Seq(item, term))
+}
+
+// EndBy1 matches one or more item, each followed by term.
+func EndBy1(item, term Iteratee) Iteratee {
+	return Plus(Seq(item, term))
+}
+
+// ChainLeft1 matches one or more term separated by op, as in a
+// left-associative binary operator expression. Values are built by the
+// caller, typically on a stack pushed by term: reduce is called with the
+// tokens of each op as soon as its right operand is matched, so that
+// "a - b - c" reduces as "(a - b) - c".
+func ChainLeft1(term, op Iteratee, reduce func(op [][]byte)) Iteratee {
+	return Seq(term, Star(Bind(op, func(o [][]byte) (Iteratee, error) {
+		return Capture(term, func([][]byte) { reduce(o) }), nil
+	})))
+}
+
+// ChainRight1 is like ChainLeft1 but for right-associative operators:
+// reduce is called after all the operands are matched, from the last op
+// to the first, so that "a ^ b ^ c" reduces as "a ^ (b ^ c)".
+func ChainRight1(term, op Iteratee, reduce func(op [][]byte)) Iteratee {
+	return Seq(term, Rec(func(rest Iteratee) Iteratee {
+		return RepeatRange(Bind(op, func(o [][]byte) (Iteratee, error) {
+			return Capture(Seq(term, rest), func([][]byte) { reduce(o) }), nil
+		}), 0, 1)
+	}))
+}
+
+// repeatI implements RepeatRange(). N is the number of completed
+// repetitions.
+type repeatI struct {
+	A           Iteratee
+	N, Min, Max int
+}
+
+func (it repeatI) Final() error {
+	if it.N >= it.Min {
+		return nil
+	}
+	if err := it.A.Final(); err != nil {
+		return RepeatErr{it.Min, it.Max, it.N, err}
+	}
+	return nil
+}
+
+func (it repeatI) Next(token []byte) (Iteratee, bool, error) {
+	if it.Max >= 0 && it.N >= it.Max {
+		return nil, false, nil
+	}
+	next, read, err := step(it.A, token)
+	if err != nil {
+		if isCut(err) {
+			return nil, false, err
+		}
+		if it.N < it.Min {
+			return nil, false, RepeatErr{it.Min, it.Max, it.N, err}
+		}
+		return nil, false, nil
+	}
+	if !read {
+		// A matched nothing and will do so again on the same token, so
+		// the remaining repetitions are all empty.
+		return nil, false, nil
+	}
+	if it.N+1 == it.Max && next == nil {
+		// The last repetition is done, so do not wait for another token.
+		return nil, true, nil
+	}
+	rest := repeatI{it.A, it.N + 1, it.Min, it.Max}
+	if next != nil {
+		return thenI{next, rest}, true, nil
+	}
+	return rest, true, nil
+}
+
+// RepeatErr reports that a repetition failed before the minimum number
+// of repetitions is reached.
+type RepeatErr struct {
+	Min, Max int   // the expected range of repetitions.
+	N        int   // the number of completed repetitions.
+	Err      error // the error of the failed repetition.
+}
+
+func (e RepeatErr) Error() string {
+	bound := "at least "
+	if e.Min == e.Max {
+		bound = ""
+	}
+	return fmt.Sprintf("expect %s%s, got %d: %v", bound, plural(e.Min, "repetition"), e.N, e.Err)
+}
+
+func (e RepeatErr) Unwrap() error { return e.Err }
+
+// plural formats n with noun in the appropriate number.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,229 @@
+package stream
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRepeat(t *testing.T) {
+	octet := Seq(Match("x"), SkipAny("."))
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		OK    bool
+	}{
+		{"x . x . x . x", Seq(Repeat(octet, 4), EOF), true},
+		{"x . x . x", Seq(Repeat(octet, 4), EOF), false},
+		{"x . x . x . x . x", Seq(Repeat(octet, 4), EOF), false},
+		{"", Repeat(octet, 0), true},
+		{"a b", Seq(Repeat(Seq(Skip, Skip), 1), EOF), true},
+		{"a", Seq(Repeat(Seq(Skip, Skip), 1), EOF), false},
+		{"x x", Seq(RepeatRange(Match("x"), 1, 3), EOF), true},
+		{"x x x", Seq(RepeatRange(Match("x"), 1, 3), EOF), true},
+		{"x x x x", Seq(RepeatRange(Match("x"), 1, 3), EOF), false},
+		{"y", Seq(RepeatRange(Match("x"), 1, 3), Match("y")), false},
+		{"x x x x x y", Seq(RepeatRange(Match("x"), 2, -1), Match("y")), true},
+		// Empty repetitions do not loop forever.
+		{"y", Seq(RepeatRange(SkipAny("x"), 2, -1), Match("y")), true},
+	} {
+		err := runWords(i.Input, i.It)
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+
+	err := runWords("x x y", Repeat(Match("x"), 3))
+	if expected := `token "y" at offset 4 (token #2): expect 3 repetitions, got 2: expect "x"`; err == nil || err.Error() != expected {
+		t.Errorf("expect %q; got %v", expected, err)
+	}
+
+	// An exact repetition finishes on its last token.
+	f := NewFeeder(Repeat(Match("x"), 2))
+	for _, token := range []string{"x", "x"} {
+		if err := f.Push([]byte(token)); err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	if !f.Done() {
+		t.Error("expect Repeat to finish after the last repetition")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expect a panic on min > max")
+		}
+	}()
+	RepeatRange(Match("x"), 3, 2)
+}
+
+func TestPlus(t *testing.T) {
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"a", true},
+		{"a a a", true},
+		{"", false},
+		{"b", false},
+		{"a b", false},
+	} {
+		err := runWords(i.Input, Seq(Plus(Match("a")), EOF))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+
+	if err, ok := Plus(Match("a")).Final().(RepeatErr); !ok || err.Error() != `expect at least 1 repetition, got 0: expect "a"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestStarStrict(t *testing.T) {
+	// An item may start with a transition that consumes nothing.
+	item := Seq(Star(Match("-")), Match("x"))
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		Err   string
+	}{
+		{"x - x ;", StarStrict(item), ""},
+		{";", StarStrict(item), ""},
+		{"x - x ;", Star(item), `token ";" at offset 6 (token #3): expect "x"`},
+		{"x - ;", StarStrict(item), `token ";" at offset 4 (token #2): expect "x"`},
+		{"x - x ;", StarMin(item, 2), ""},
+		{"x ;", StarMin(item, 2), `token ";" at offset 2 (token #1): expect at least 2 repetitions, got 1: expect "x"`},
+		// An empty repetition does not loop forever.
+		{";", StarStrict(Star(Match("x"))), ""},
+	} {
+		err := runWords(i.Input, Seq(i.It, Match(";"), EOF))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
+
+func TestSepBy(t *testing.T) {
+	args := func(sepBy func(item, sep Iteratee) Iteratee) Iteratee {
+		return Seq(Match("("), sepBy(Match("x"), Match(",")), Match(")"), EOF)
+	}
+	for _, i := range []struct {
+		Input                              string
+		SepBy, SepBy1, SepEndBy, SepEndBy1 bool
+	}{
+		{"( )", true, false, true, false},
+		{"( x )", true, true, true, true},
+		{"( x , x , x )", true, true, true, true},
+		{"( x , x , )", false, false, true, true},
+		{"( , )", false, false, false, false},
+		{"( x x )", false, false, false, false},
+		{"( x , , x )", false, false, false, false},
+	} {
+		for _, c := range []struct {
+			Name  string
+			SepBy func(item, sep Iteratee) Iteratee
+			OK    bool
+		}{
+			{"SepBy", SepBy, i.SepBy},
+			{"SepBy1", SepBy1, i.SepBy1},
+			{"SepEndBy", SepEndBy, i.SepEndBy},
+			{"SepEndBy1", SepEndBy1, i.SepEndBy1},
+		} {
+			err := runWords(i.Input, args(c.SepBy))
+			if c.OK && err != nil {
+				t.Errorf("%s: input %q: unexpected error %v", c.Name, i.Input, err)
+			} else if !c.OK && err == nil {
+				t.Errorf("%s: input %q: expect error", c.Name, i.Input)
+			}
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	var stack []int
+	num := MatchFunc(func(token []byte) bool {
+		n, err := strconv.Atoi(string(token))
+		stack = append(stack, n)
+		return err == nil
+	}, "number")
+	reduce := func(op [][]byte) {
+		a, b := stack[len(stack)-2], stack[len(stack)-1]
+		stack = stack[:len(stack)-2]
+		switch string(op[0]) {
+		case "-":
+			a -= b
+		case "^":
+			n := 1
+			for ; b > 0; b-- {
+				n *= a
+			}
+			a = n
+		}
+		stack = append(stack, a)
+	}
+	for _, i := range []struct {
+		Input string
+		It    Iteratee
+		Value int
+	}{
+		{"7", ChainLeft1(num, Match("-"), reduce), 7},
+		{"10 - 3 - 2", ChainLeft1(num, Match("-"), reduce), 5},
+		{"2 ^ 3 ^ 2", ChainRight1(num, Match("^"), reduce), 512},
+		{"2 ^ 3", ChainRight1(num, Match("^"), reduce), 8},
+		// ^ binds tighter than -.
+		{"10 - 2 ^ 3 - 1", ChainLeft1(ChainRight1(num, Match("^"), reduce), Match("-"), reduce), 1},
+	} {
+		stack = nil
+		if err := runWords(i.Input, Seq(i.It, EOF)); err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if len(stack) != 1 || stack[0] != i.Value {
+			t.Errorf("input %q: expect %d; got %v", i.Input, i.Value, stack)
+		}
+	}
+
+	if err := runWords("1 - - 2", Seq(ChainLeft1(num, Match("-"), reduce), EOF)); err == nil {
+		t.Error("expect error")
+	}
+}
+
+func TestEndBy(t *testing.T) {
+	stmt := Seq(Match("x"), Star(Match("y")))
+	for _, i := range []struct {
+		Input         string
+		EndBy, EndBy1 bool
+	}{
+		{"", true, false},
+		{"x ;", true, true},
+		{"x y y ; x ;", true, true},
+		{"x ; x", false, false},
+		{"x ; ;", false, false},
+		{";", false, false},
+		{"x y", false, false},
+	} {
+		for _, c := range []struct {
+			Name  string
+			EndBy func(item, term Iteratee) Iteratee
+			OK    bool
+		}{
+			{"EndBy", EndBy, i.EndBy},
+			{"EndBy1", EndBy1, i.EndBy1},
+		} {
+			err := runWords(i.Input, Seq(c.EndBy(stmt, Match(";")), EOF))
+			if c.OK && err != nil {
+				t.Errorf("%s: input %q: unexpected error %v", c.Name, i.Input, err)
+			} else if !c.OK && err == nil {
+				t.Errorf("%s: input %q: expect error", c.Name, i.Input)
+			}
+		}
+	}
+
+	// A missing terminator is reported as such.
+	if err := runWords("x ; x y }", EndBy(stmt, Match(";"))); err == nil || err.Error() != `token "}" at offset 8 (token #4): expect ";"` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// LineReader reads a line of input after showing prompt, e.g. a line
+// editing library wrapped as
+//
+//	func (r rl) ReadLine(prompt string) (string, error) {
+//		r.SetPrompt(prompt)
+//		return r.Readline()
+//	}
+//
+// It returns io.EOF at the end of input.
+type LineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// NewPromptReader creates a LineReader that writes the prompt to out and
+// then reads a line from in, e.g. os.Stdin, whose terminal does any line
+// editing.
+func NewPromptReader(in io.Reader, out io.Writer) LineReader {
+	return promptReader{bufio.NewReader(in), out}
+}
+
+// promptReader implements NewPromptReader().
+type promptReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (r promptReader) ReadLine(prompt string) (string, error) {
+	if _, err := io.WriteString(r.out, prompt); err != nil {
+		return "", err
+	}
+	line, err := r.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return string(bytes.TrimRight([]byte(line), "\r\n")), err
+}
+
+// REPLEnumerator is an Enumerator for interactive input, which reads a
+// line only when the Iteratee needs another token, showing a prompt
+// first. Each line is split into tokens by a bufio.SplitFunc. The prompt
+// is the primary one between statements and the continuation one in the
+// middle of a statement (see Statement).
+//
+// After an error, the rest of the line is discarded and the next prompt
+// is the primary one, so that a new Iteratee may be run on the following
+// input, e.g.
+//
+//	for {
+//		err := Run(r, Seq(r.Statement(stmt), ...))
+//		if err == nil {
+//			break
+//		}
+//		fmt.Println(err)
+//	}
+type REPLEnumerator struct {
+	r              LineReader
+	split          bufio.SplitFunc
+	prompt, cont   string
+	pending        [][]byte // the tokens left in the current line.
+	inStmt, closed bool
+	index          int
+}
+
+// NewREPLEnumerator creates a REPLEnumerator reading lines from r with
+// the given primary and continuation prompts. Each line is split by
+// split, or is a single token if split is nil; an empty line makes no
+// token.
+func NewREPLEnumerator(r LineReader, split bufio.SplitFunc, prompt, cont string) *REPLEnumerator {
+	return &REPLEnumerator{r: r, split: split, prompt: prompt, cont: cont}
+}
+
+func (e *REPLEnumerator) Step(it Iteratee) (Iteratee, error) {
+	for len(e.pending) == 0 {
+		if e.closed {
+			return nil, it.Final()
+		}
+		prompt := e.prompt
+		if e.inStmt {
+			prompt = e.cont
+		}
+		line, err := e.r.ReadLine(prompt)
+		if err == io.EOF {
+			e.closed = true
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if e.pending, err = e.tokens(line); err != nil {
+			return nil, err
+		}
+	}
+	token := e.pending[0]
+	next, read, err := it.Next(token)
+	if err != nil {
+		e.pending, e.inStmt = nil, false
+		return nil, WrapTokenErrorAt(token, -1, e.index, err)
+	}
+	if read {
+		e.pending = e.pending[1:]
+		e.index++
+	}
+	return next, nil
+}
+
+// tokens splits line into tokens.
+func (e *REPLEnumerator) tokens(line string) ([][]byte, error) {
+	if e.split == nil {
+		if line == "" {
+			return nil, nil
+		}
+		return [][]byte{[]byte(line)}, nil
+	}
+	in := bufio.NewScanner(bytes.NewReader([]byte(line)))
+	in.Split(e.split)
+	var tokens [][]byte
+	for in.Scan() {
+		tokens = append(tokens, append([]byte(nil), in.Bytes()...))
+	}
+	return tokens, in.Err()
+}
+
+// Statement marks it as a statement: once it has consumed a token, the
+// continuation prompt is shown until it reaches a final state or until
+// an Iteratee created by EndStatement finishes. Since many Iteratees,
+// e.g. Seq, only reach their final state on the token after their
+// match, the token that ends a statement, e.g. a semicolon, is best
+// matched by EndStatement.
+func (e *REPLEnumerator) Statement(it Iteratee) Iteratee {
+	return stmtI{e, it, false}
+}
+
+// stmtI implements REPLEnumerator.Statement(). Started is set once A
+// has consumed a token.
+type stmtI struct {
+	E       *REPLEnumerator
+	A       Iteratee
+	Started bool
+}
+
+func (it stmtI) Final() error {
+	it.E.inStmt = false
+	return it.A.Final()
+}
+
+func (it stmtI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		return nil, false, err
+	}
+	if next == nil {
+		it.E.inStmt = false
+		return nil, read, nil
+	}
+	if read && !it.Started {
+		it.E.inStmt, it.Started = true, true
+	}
+	it.A = next
+	return it, read, nil
+}
+
+// EndStatement marks the end of the current statement (see Statement)
+// once it finishes after consuming a token.
+func (e *REPLEnumerator) EndStatement(it Iteratee) Iteratee {
+	return endStmtI{e, it}
+}
+
+// endStmtI implements REPLEnumerator.EndStatement().
+type endStmtI struct {
+	E *REPLEnumerator
+	A Iteratee
+}
+
+func (it endStmtI) Final() error { return it.A.Final() }
+func (it endStmtI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		return nil, false, err
+	}
+	if next == nil {
+		if read {
+			it.E.inStmt = false
+		}
+		return nil, read, nil
+	}
+	it.A = next
+	return it, read, nil
+}
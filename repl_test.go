@@ -0,0 +1,48 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestREPLEnumerator(t *testing.T) {
+	var out bytes.Buffer
+	in := strings.NewReader("let x\n\n= 1 ;\nlet y = ;\nlet z = 2 ;\n")
+	e := NewREPLEnumerator(NewPromptReader(in, &out), bufio.ScanWords, "> ", ". ")
+	var stmts []string
+	stmt := e.Statement(Capture(Seq(Match("let"), Identifier(), Match("="), Int(), e.EndStatement(Match(";"))), appendTo(&stmts)))
+
+	err := Run(e, Seq(Star(stmt), EOF))
+	if want := `token ";" (token #8): expect integer`; err == nil || err.Error() != want {
+		t.Errorf("expect error %q; got %v", want, err)
+	}
+	// The rest of the input after an error.
+	if err := Run(e, Seq(Star(stmt), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if got := strings.Join(stmts, " | "); got != "let x = 1 ; | let z = 2 ;" {
+		t.Errorf("expect statements; got %q", got)
+	}
+	if want := "> . . > > > "; out.String() != want {
+		t.Errorf("expect prompts %q; got %q", want, out.String())
+	}
+
+	// Without EndStatement, a Seq only ends the statement on the next
+	// token, i.e. after the next line is read.
+	out.Reset()
+	e = NewREPLEnumerator(NewPromptReader(strings.NewReader("a ;\nb\n;\n"), &out), bufio.ScanWords, "> ", ". ")
+	if err := Run(e, Seq(Star(e.Statement(Seq(Skip, Match(";")))), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if want := "> . . . "; out.String() != want {
+		t.Errorf("expect prompts %q; got %q", want, out.String())
+	}
+
+	// Whole lines.
+	e = NewREPLEnumerator(NewPromptReader(strings.NewReader("a\n\nb"), &out), nil, "", "")
+	if err := Run(e, Seq(Match("a"), Match("b"), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package stream
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// NewRuneEnumerator creates a ScanEnumerator over the UTF-8 encoded
+// runes read from r, one rune per token. Unlike bufio.ScanRunes, it
+// fails with a UTF8Err on invalid encoding instead of producing
+// U+FFFD.
+func NewRuneEnumerator(r io.Reader) *ScanEnumerator {
+	e := NewScanEnumerator(bufio.NewScanner(r))
+	e.in.Split(e.track(func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) == 0 || !atEOF && !utf8.FullRune(data) {
+			return 0, nil, nil
+		}
+		if r, size := utf8.DecodeRune(data); r != utf8.RuneError || size > 1 {
+			return size, data[:size], nil
+		}
+		return 0, nil, UTF8Err{e.consumed, data[0]}
+	}))
+	return e
+}
+
+// UTF8Err reports invalid UTF-8 encoding.
+type UTF8Err struct {
+	Offset int  // byte offset of the invalid byte.
+	Byte   byte // the first invalid byte.
+}
+
+func (e UTF8Err) Error() string {
+	return fmt.Sprintf("invalid UTF-8 byte %#x at offset %d", e.Byte, e.Offset)
+}
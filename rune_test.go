@@ -0,0 +1,28 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRuneEnumerator(t *testing.T) {
+	quoted := Seq(Match("«"), Star(Seq(Not(Match("»")), Skip)), Match("»"), EOF)
+	for _, i := range []struct {
+		Input, Err string
+	}{
+		{"«héllo, 世界»", ""},
+		{"«�»", ""},
+		{"«ab\xffc»", "invalid UTF-8 byte 0xff at offset 4"},
+		{"«ab\xe4\xb8»", "invalid UTF-8 byte 0xe4 at offset 4"},
+		{"«ab\xe4\xb8", "invalid UTF-8 byte 0xe4 at offset 4"},
+		{"«ab", `expect "»"`},
+		{"xé", `token "x" at offset 0 (token #0): expect "«"`},
+	} {
+		err := Run(NewRuneEnumerator(strings.NewReader(i.Input)), quoted)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
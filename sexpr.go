@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SplitSExpr is a bufio.SplitFunc for S-expressions. The tokens are:
+//
+//   - the brackets "(", ")", "[", "]", "{" and "}";
+//   - the quote characters "'", "`", "," and ",@";
+//   - strings in double quotes, where a backslash escapes the next byte,
+//     kept with their quotes and escapes (see strconv.Unquote);
+//   - atoms, which run until space, a bracket, a quote character, a
+//     double quote or a semicolon.
+//
+// Space and comments, from ";" to the end of the line, are skipped. A
+// string left open at the end of input fails with ErrUnclosedQuote.
+func SplitSExpr(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	i := 0
+	for i < len(data) {
+		r, size := utf8.DecodeRune(data[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+		case r == ';':
+			end := bytes.IndexByte(data[i:], '\n')
+			if end < 0 && atEOF {
+				return len(data), nil, nil
+			}
+			if end < 0 {
+				return i, nil, nil
+			}
+			i += end + 1
+			continue
+		}
+		break
+	}
+	if i == len(data) {
+		return i, nil, nil
+	}
+	switch b := data[i]; b {
+	case '(', ')', '[', ']', '{', '}', '\'', '`':
+		return i + 1, data[i : i+1], nil
+	case ',':
+		if i+1 == len(data) && !atEOF {
+			return i, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '@' {
+			return i + 2, data[i : i+2], nil
+		}
+		return i + 1, data[i : i+1], nil
+	case '"':
+		for j := i + 1; j < len(data); j++ {
+			switch data[j] {
+			case '\\':
+				j++
+			case '"':
+				return j + 1, data[i : j+1], nil
+			}
+		}
+		if atEOF {
+			return 0, nil, ErrUnclosedQuote('"')
+		}
+		return i, nil, nil
+	}
+	for j := i; j < len(data); {
+		r, size := utf8.DecodeRune(data[j:])
+		if unicode.IsSpace(r) || isSExprDelim(r) {
+			return j, data[i:j], nil
+		}
+		j += size
+	}
+	if atEOF {
+		return len(data), data[i:], nil
+	}
+	return i, nil, nil
+}
+
+// isSExprDelim tells whether r ends an atom in SplitSExpr.
+func isSExprDelim(r rune) bool {
+	switch r {
+	case '(', ')', '[', ']', '{', '}', '\'', '`', ',', '"', ';':
+		return true
+	}
+	return false
+}
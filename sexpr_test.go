@@ -0,0 +1,32 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitSExpr(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Tokens string
+		Err    string
+	}{
+		{"(define (f x) [+ x 1])", "(|define|(|f|x|)|[|+|x|1|]|)", ""},
+		{`(say "a (b) \"c\"" 'd)`, `(|say|"a (b) \"c\""|'|d|)`, ""},
+		{"`(a ,b ,@c)", "`|(|a|,|b|,@|c|)", ""},
+		{"a ; comment (\n b;c\n", "a|b", ""},
+		{"λx y", "λx|y", ""},
+		{`(a "b`, "(|a", `unclosed quote '"'`},
+		{"a,", "a|,", ""},
+	} {
+		tokens, err := splitAll(i.Input, SplitSExpr)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package stream
+
+import (
+	"bytes"
+	"strings"
+)
+
+// ShellSplit is a SplitState for POSIX shell words, e.g. to parse
+// command lines or shell history files. The tokens are:
+//
+//   - words, with quotes and escapes removed: a backslash escapes the
+//     next character, single quotes keep everything up to the next
+//     single quote, and inside double quotes a backslash only escapes
+//     "$", "`", `"`, a backslash or a newline;
+//   - the operators "&&", "||", ";;", "<<", ">>", "<&", ">&", "<>", ">|",
+//     "|", "&", ";", "<", ">", "(" and ")", where a redirection keeps a
+//     file descriptor number written right before it, e.g. "2>&";
+//   - "\n" for each unescaped newline, which ends a command.
+//
+// Comments, from a "#" at the start of a word to the end of the line,
+// and escaped newlines are skipped. Since quotes are removed, a quoted
+// word equal to an operator, e.g. '|', makes the same token as the
+// operator. Variables and other expansions are not interpreted. A quote
+// left open at the end of input fails with ErrUnclosedQuote.
+type ShellSplit struct{}
+
+// shellOperators lists the operators of ShellSplit, longest first.
+var shellOperators = []string{"&&", "||", ";;", "<<", ">>", "<&", ">&", "<>", ">|", "|", "&", ";", "<", ">", "(", ")"}
+
+func (s ShellSplit) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	i := 0
+	for i < len(data) {
+		switch {
+		case data[i] == ' ' || data[i] == '\t':
+			i++
+			continue
+		case data[i] == '\\' && i+1 < len(data) && data[i+1] == '\n':
+			i += 2
+			continue
+		case data[i] == '\\' && i+1 == len(data) && !atEOF:
+			return s, i, nil, nil
+		}
+		break
+	}
+	if i == len(data) {
+		return s, i, nil, nil
+	}
+	switch b := data[i]; {
+	case b == '\n':
+		return s, i + 1, data[i : i+1], nil
+	case b == '#':
+		next, advance, _, _ := shellComment{}.Next(data[i:], atEOF)
+		return next, i + advance, nil, nil
+	case isShellOperator(b):
+		// An operator may go on, e.g. "&" may be the start of "&&".
+		if i+1 == len(data) && !atEOF {
+			return s, i, nil, nil
+		}
+		op := shellOperator(data[i:])
+		return s, i + len(op), data[i : i+len(op)], nil
+	}
+	return s.word(data, i, atEOF)
+}
+
+// shellComment is the state of ShellSplit inside a comment, which is
+// skipped as it is read.
+type shellComment struct{}
+
+func (s shellComment) Next(data []byte, atEOF bool) (SplitState, int, []byte, error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return ShellSplit{}, i, nil, nil
+	}
+	return s, len(data), nil, nil
+}
+
+// word splits the word that starts at data[i].
+func (s ShellSplit) word(data []byte, i int, atEOF bool) (SplitState, int, []byte, error) {
+	word := []byte{}
+	digits := true // the word so far is only digits.
+	for j := i; j < len(data); j++ {
+		b := data[j]
+		switch {
+		case b == ' ' || b == '\t' || b == '\n':
+			return s, j, word, nil
+		case isShellOperator(b):
+			if digits && (b == '<' || b == '>') {
+				// A file descriptor number before a redirection.
+				if j+1 == len(data) && !atEOF {
+					return s, i, nil, nil
+				}
+				op := shellOperator(data[j:])
+				return s, j + len(op), data[i : j+len(op)], nil
+			}
+			return s, j, word, nil
+		case b == '\\':
+			if j+1 == len(data) {
+				if !atEOF {
+					return s, i, nil, nil
+				}
+				word = append(word, b)
+				continue
+			}
+			j++
+			if data[j] != '\n' {
+				word = append(word, data[j])
+			}
+		case b == '\'':
+			end := bytes.IndexByte(data[j+1:], '\'')
+			if end < 0 {
+				return s.more(i, atEOF, '\'')
+			}
+			end += j + 1
+			word = append(word, data[j+1:end]...)
+			j = end
+		case b == '"':
+			end := -1
+			for k := j + 1; k < len(data) && end < 0; k++ {
+				switch data[k] {
+				case '\\':
+					if k+1 == len(data) {
+						break
+					}
+					k++
+					if !strings.ContainsRune("$`\"\\\n", rune(data[k])) {
+						word = append(word, '\\')
+					}
+					if data[k] != '\n' {
+						word = append(word, data[k])
+					}
+				case '"':
+					end = k
+				default:
+					word = append(word, data[k])
+				}
+			}
+			if end < 0 {
+				return s.more(i, atEOF, '"')
+			}
+			j = end
+		default:
+			word = append(word, b)
+		}
+		digits = digits && '0' <= b && b <= '9'
+	}
+	if !atEOF {
+		return s, i, nil, nil
+	}
+	return s, len(data), word, nil
+}
+
+// more asks for more input from i, or fails at the end of input inside
+// a quote.
+func (s ShellSplit) more(i int, atEOF bool, quote byte) (SplitState, int, []byte, error) {
+	if atEOF {
+		return s, 0, nil, ErrUnclosedQuote(quote)
+	}
+	return s, i, nil, nil
+}
+
+func isShellOperator(b byte) bool { return strings.IndexByte("&|;<>()", b) >= 0 }
+
+// shellOperator returns the longest operator at the start of data.
+func shellOperator(data []byte) []byte {
+	for _, op := range shellOperators {
+		if strings.HasPrefix(string(data), op) {
+			return data[:len(op)]
+		}
+	}
+	return data[:1]
+}
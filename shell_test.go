@@ -0,0 +1,35 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShellSplit(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Tokens string
+		Err    string
+	}{
+		{`ls -l "my dir" 'a b'c|wc -l`, "ls|-l|my dir|a bc|||wc|-l", ""},
+		{"make && echo ok || exit 1; cd /tmp&", "make|&&|echo|ok||||exit|1|;|cd|/tmp|&", ""},
+		{"cmd >out 2>&1 <in 2>>log", "cmd|>|out|2>&|1|<|in|2>>|log", ""},
+		{`echo a\ b "\$x \y" '\n'`, `echo|a b|$x \y|\n`, ""},
+		{"echo a # comment\necho b\\\nc", "echo|a|\n|echo|bc", ""},
+		{`echo "" x`, "echo||x", ""},
+		{`echo "a`, "echo", `unclosed quote '"'`},
+		{"echo 'a", "echo", `unclosed quote '\''`},
+		{"a2>b", "a2|>|b", ""},
+		{"a   # c\nb", "a|\n|b", ""},
+	} {
+		tokens, err := splitAll(i.Input, StatefulSplitFunc(ShellSplit{}))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+}
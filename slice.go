@@ -0,0 +1,89 @@
+package stream
+
+// SliceEnumerator is an Enumerator over tokens in memory. Offsets in
+// errors are those in the concatenation of the tokens.
+type SliceEnumerator struct {
+	tokens [][]byte
+	// index is the position of the current token in tokens and offset
+	// is its byte offset.
+	index, offset int
+}
+
+// NewSliceEnumerator creates a SliceEnumerator over tokens, which must
+// not be modified while it is in use.
+func NewSliceEnumerator(tokens [][]byte) *SliceEnumerator {
+	return &SliceEnumerator{tokens: tokens}
+}
+
+// NewStringsEnumerator creates a SliceEnumerator over tokens.
+func NewStringsEnumerator(tokens []string) *SliceEnumerator {
+	bs := make([][]byte, len(tokens))
+	for i, t := range tokens {
+		bs[i] = []byte(t)
+	}
+	return NewSliceEnumerator(bs)
+}
+
+func (e *SliceEnumerator) Step(it Iteratee) (Iteratee, error) {
+	if e.index == len(e.tokens) {
+		return nil, it.Final()
+	}
+	token := e.tokens[e.index]
+	next, read, err := it.Next(token)
+	if err != nil {
+		return nil, WrapTokenErrorAt(token, e.offset, e.index, err)
+	}
+	if read {
+		e.index++
+		e.offset += len(token)
+	}
+	return next, nil
+}
+
+// LoopEnumerator is an Enumerator that replays tokens a number of times,
+// or forever, with separator tokens between the iterations, e.g. to
+// drive soak tests. Offsets in errors are those in the concatenation of
+// all tokens presented so far.
+type LoopEnumerator struct {
+	tokens, sep [][]byte
+	n           int // the number of iterations left; negative for forever.
+	// cur is the rest of the current iteration, or of the separator when
+	// inSep is true. started is true once an iteration has started.
+	cur            [][]byte
+	inSep, started bool
+	index, offset  int
+}
+
+// NewLoopEnumerator creates a LoopEnumerator presenting tokens n times,
+// or forever if n < 0, with sep between the iterations. Neither may be
+// modified while it is in use.
+func NewLoopEnumerator(tokens [][]byte, n int, sep ...[]byte) *LoopEnumerator {
+	return &LoopEnumerator{tokens: tokens, sep: sep, n: n}
+}
+
+func (e *LoopEnumerator) Step(it Iteratee) (Iteratee, error) {
+	for len(e.cur) == 0 {
+		if e.n == 0 || len(e.tokens) == 0 && len(e.sep) == 0 {
+			return nil, it.Final()
+		}
+		if e.inSep || !e.started {
+			e.cur, e.inSep, e.started = e.tokens, false, true
+			if e.n > 0 {
+				e.n--
+			}
+		} else {
+			e.cur, e.inSep = e.sep, true
+		}
+	}
+	token := e.cur[0]
+	next, read, err := it.Next(token)
+	if err != nil {
+		return nil, WrapTokenErrorAt(token, e.offset, e.index, err)
+	}
+	if read {
+		e.cur = e.cur[1:]
+		e.index++
+		e.offset += len(token)
+	}
+	return next, nil
+}
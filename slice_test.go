@@ -0,0 +1,68 @@
+package stream
+
+import "testing"
+
+func TestSliceEnumerator(t *testing.T) {
+	list := Seq(Match("("), Star(Match("x")), Match(")"), EOF)
+	for _, i := range []struct {
+		Input []string
+		Err   string
+	}{
+		{[]string{"(", "x", "x", ")"}, ""},
+		{[]string{"(", ")"}, ""},
+		{[]string{"(", "x", "y"}, `token "y" at offset 2 (token #2): expect ")"`},
+		{[]string{"(", "x"}, `expect ")"`},
+		{nil, `expect "("`},
+	} {
+		err := Run(NewStringsEnumerator(i.Input), list)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+
+	// Tokens may contain anything, including spaces.
+	if err := Run(NewSliceEnumerator([][]byte{[]byte("a b"), nil}), Seq(Match("a b"), Match(""), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestLoopEnumerator(t *testing.T) {
+	tokens := [][]byte{[]byte("a"), []byte("b")}
+	for _, i := range []struct {
+		N      int
+		Sep    []string
+		Tokens string
+	}{
+		{3, nil, "a b a b a b"},
+		{2, []string{";", "\n"}, "a b ; \n a b"},
+		{1, []string{";"}, "a b"},
+		{0, []string{";"}, ""},
+	} {
+		var sep [][]byte
+		for _, s := range i.Sep {
+			sep = append(sep, []byte(s))
+		}
+		var got []string
+		if err := Run(NewLoopEnumerator(tokens, i.N, sep...), Capture(Star(Skip), appendTo(&got))); err != nil {
+			t.Errorf("n %d: unexpected error %v", i.N, err)
+		}
+		if len(got) != 1 || got[0] != i.Tokens {
+			t.Errorf("n %d: expect %q; got %q", i.N, i.Tokens, got)
+		}
+	}
+
+	// Forever, until the Iteratee stops.
+	var n int
+	count := Star(Seq(Match("a"), Match("b"), Capture(Match(";"), func([][]byte) { n++ })))
+	err := Run(NewLoopEnumerator(tokens, -1, []byte(";")), Seq(LimitTokens(count, 3000), EOF))
+	if err == nil || err.Error() != `token "a" at offset 3000 (token #3000): more than 3000 tokens` || n != 1000 {
+		t.Errorf("unexpected error %v after %d iterations", err, n)
+	}
+
+	// Nothing to loop over.
+	if err := Run(NewLoopEnumerator(nil, -1), EOF); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package stream
+
+import "errors"
+
+// MessageSource is a source of messages that must be acknowledged, e.g.
+// a consumer of a Kafka topic, an SQS queue or a NATS subscription.
+// Fetch returns the next message, or io.EOF after the last one. Ack
+// acknowledges the message last returned by Fetch, and Nack gives it
+// back to be delivered again.
+type MessageSource interface {
+	Fetch() ([]byte, error)
+	Ack() error
+	Nack() error
+}
+
+// SourceEnumerator is an Enumerator over the messages from a
+// MessageSource, one token per message, for at-least-once processing: a
+// message is acknowledged once the Iteratee has consumed it without
+// error, and given back when the Iteratee fails on it. Offsets in errors
+// are those in the concatenation of the messages.
+type SourceEnumerator struct {
+	src  MessageSource
+	slot tokenSlot
+}
+
+// NewSourceEnumerator creates a SourceEnumerator fetching from src.
+func NewSourceEnumerator(src MessageSource) *SourceEnumerator {
+	return &SourceEnumerator{src: src}
+}
+
+func (e *SourceEnumerator) Step(it Iteratee) (Iteratee, error) {
+	next, read, err := e.slot.step(it, e.src.Fetch)
+	if err != nil {
+		if e.slot.have {
+			// The Iteratee failed on the message.
+			e.slot.drop()
+			if nerr := e.src.Nack(); nerr != nil {
+				err = errors.Join(err, nerr)
+			}
+		}
+		return nil, err
+	}
+	if read {
+		if err := e.src.Ack(); err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
+// Close gives back the current message when the Iteratee has finished
+// without consuming it, so that it is delivered again. It does nothing
+// otherwise.
+func (e *SourceEnumerator) Close() error {
+	if !e.slot.have {
+		return nil
+	}
+	e.slot.drop()
+	return e.src.Nack()
+}
@@ -0,0 +1,58 @@
+package stream
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// queue is a MessageSource over a fixed list of messages, followed by
+// Err. Log records the calls of Ack and Nack.
+type queue struct {
+	List []string
+	Err  error
+	cur  string
+	Log  []string
+}
+
+func (q *queue) Fetch() ([]byte, error) {
+	if len(q.List) == 0 {
+		return nil, q.Err
+	}
+	q.cur, q.List = q.List[0], q.List[1:]
+	return []byte(q.cur), nil
+}
+
+func (q *queue) Ack() error  { q.Log = append(q.Log, "ack "+q.cur); return nil }
+func (q *queue) Nack() error { q.Log = append(q.Log, "nack "+q.cur); return nil }
+
+func TestSourceEnumerator(t *testing.T) {
+	errClosed := errors.New("closed")
+	for _, i := range []struct {
+		Msgs []string
+		End  error
+		It   Iteratee
+		Err  string
+		Log  string
+	}{
+		{[]string{"a", "b"}, io.EOF, Seq(Match("a"), Match("b"), EOF), "", "ack a, ack b"},
+		{[]string{"a", "c"}, io.EOF, Seq(Match("a"), Match("b")), `token "c" at offset 1 (token #1): expect "b"`, "ack a, nack c"},
+		{[]string{"a"}, errClosed, Seq(Match("a"), Match("b")), "closed", "ack a"},
+		// The Iteratee does not consume c.
+		{[]string{"a", "c"}, io.EOF, Star(Match("a")), "", "ack a, nack c"},
+	} {
+		q := &queue{List: i.Msgs, Err: i.End}
+		e := NewSourceEnumerator(q)
+		err := Run(e, i.It)
+		if i.Err == "" && err != nil {
+			t.Errorf("messages %q: unexpected error %v", i.Msgs, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("messages %q: expect error %q; got %v", i.Msgs, i.Err, err)
+		}
+		e.Close()
+		if log := strings.Join(q.Log, ", "); log != i.Log {
+			t.Errorf("messages %q: expect %q; got %q", i.Msgs, i.Log, log)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SplitByRegexp returns a bufio.SplitFunc for a tokenizer described by
+// re: at each point of the input, re must match a non-empty prefix of
+// the rest, which is the token; if re has a capturing group, the token
+// is its first group instead, and a match in which the group does not
+// take part is skipped, e.g. `\s*(\w+|[()])?`. Input that does not match
+// fails with ErrNoMatch. A match that might go on with more input is
+// only taken once that input has been read, so that the result does not
+// depend on how the input is buffered.
+func SplitByRegexp(re *regexp.Regexp) bufio.SplitFunc {
+	anchored := regexp.MustCompile(`^(?:` + re.String() + `)`)
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		r := &bytesRuneReader{data: data}
+		m := anchored.FindReaderSubmatchIndex(r)
+		if r.hitEnd && !atEOF {
+			return 0, nil, nil
+		}
+		if m == nil || m[1] == 0 {
+			return 0, nil, ErrNoMatch(re.String())
+		}
+		if len(m) < 4 {
+			return m[1], data[:m[1]], nil
+		}
+		if m[2] < 0 {
+			return m[1], nil, nil
+		}
+		return m[1], data[m[2]:m[3]], nil
+	}
+}
+
+// SplitOnDelimiterRegexp returns a bufio.SplitFunc whose tokens are the
+// input between the matches of sep, which must not match the empty
+// string (ErrEmptyMatch). As with bufio.ScanLines, the input after the
+// last match is a token when it is not empty. A match of sep that might
+// go on with more input is only taken once that input has been read.
+func SplitOnDelimiterRegexp(sep *regexp.Regexp) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		r := &bytesRuneReader{data: data}
+		m := sep.FindReaderIndex(r)
+		if r.hitEnd && !atEOF {
+			return 0, nil, nil
+		}
+		if m == nil {
+			return len(data), data, nil
+		}
+		if m[0] == m[1] {
+			return 0, nil, ErrEmptyMatch
+		}
+		return m[1], data[:m[0]], nil
+	}
+}
+
+// SplitOn returns a bufio.SplitFunc whose tokens are the input
+// separated by delim, which may be several bytes long, e.g.
+// "\r\n\r\n". If keep is true, each token ends with its delimiter. As
+// with bufio.ScanLines, the input after the last delimiter is a token
+// when it is not empty. It panics if delim is empty.
+func SplitOn(delim []byte, keep bool) bufio.SplitFunc {
+	if len(delim) == 0 {
+		panic("stream: empty delimiter")
+	}
+	delim = bytes.Clone(delim)
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.Index(data, delim); i >= 0 {
+			end := i + len(delim)
+			if keep {
+				return end, data[:end], nil
+			}
+			return end, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// SplitCRLF returns a bufio.SplitFunc for lines that must end with
+// "\r\n", as in SMTP, HTTP or IMAP. Each token is a line without its
+// end. A bare "\r" or "\n" fails with ErrBareLineEnd, a line longer than
+// max bytes (without its end) with ErrTokenSize, and a last line without
+// an end with io.ErrUnexpectedEOF.
+func SplitCRLF(max int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		i := bytes.IndexAny(data[:min(len(data), max+1)], "\r\n")
+		switch {
+		case i < 0 && len(data) > max:
+			return 0, nil, ErrTokenSize(max)
+		case i < 0 && atEOF:
+			return 0, nil, io.ErrUnexpectedEOF
+		case i < 0:
+			return 0, nil, nil
+		case data[i] == '\n':
+			return 0, nil, ErrBareLineEnd('\n')
+		case i+1 < len(data) && data[i+1] == '\n':
+			return i + 2, data[:i], nil
+		case i+1 < len(data) || atEOF:
+			return 0, nil, ErrBareLineEnd('\r')
+		}
+		return 0, nil, nil
+	}
+}
+
+// ErrBareLineEnd reports a "\r" or "\n" that is not part of "\r\n".
+type ErrBareLineEnd byte
+
+func (e ErrBareLineEnd) Error() string { return fmt.Sprintf("bare %q without CRLF", rune(e)) }
+
+// bytesRuneReader is an io.RuneReader over data that records whether a
+// read has reached its end, i.e. whether the result of a regexp might
+// change with more data.
+type bytesRuneReader struct {
+	data   []byte
+	i      int
+	hitEnd bool
+}
+
+func (r *bytesRuneReader) ReadRune() (rune, int, error) {
+	if r.i >= len(r.data) {
+		r.hitEnd = true
+		return 0, 0, errEndOfData
+	}
+	if !utf8.FullRune(r.data[r.i:]) {
+		// The rest of the rune may be in the data that follows.
+		r.hitEnd = true
+	}
+	c, size := utf8.DecodeRune(r.data[r.i:])
+	r.i += size
+	return c, size, nil
+}
+
+// errEndOfData ends the input of a bytesRuneReader.
+var errEndOfData = errors.New("end of data")
+
+// ErrNoMatch reports input that does not match the regular expression
+// of a tokenizer.
+type ErrNoMatch string
+
+func (e ErrNoMatch) Error() string { return "input does not match /" + string(e) + "/" }
+
+// ErrEmptyMatch reports a delimiter that matches the empty string.
+var ErrEmptyMatch = errors.New("delimiter matches the empty string")
+
+// ScanQuotedWords is a bufio.SplitFunc like bufio.ScanWords, except that
+// a span quoted by single or double quotes is part of the word even if
+// it contains space, e.g. `name="a b"` is one word. A backslash escapes
+// the next byte, inside quotes or not. Quotes and backslashes are kept
+// in the token (see QuotedString to match one, and strconv.Unquote to
+// interpret it). A quote left open at the end of input fails with
+// ErrUnclosedQuote.
+func ScanQuotedWords(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := 0
+	for start < len(data) {
+		r, size := utf8.DecodeRune(data[start:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += size
+	}
+	var quote byte
+	for i := start; i < len(data); {
+		switch b := data[i]; {
+		case b == '\\':
+			i += 2
+			continue
+		case quote != 0:
+			if b == quote {
+				quote = 0
+			}
+		case b == '"' || b == '\'':
+			quote = b
+		default:
+			r, size := utf8.DecodeRune(data[i:])
+			if unicode.IsSpace(r) {
+				return i + size, data[start:i], nil
+			}
+			i += size
+			continue
+		}
+		i++
+	}
+	if !atEOF {
+		return start, nil, nil
+	}
+	if quote != 0 {
+		return 0, nil, ErrUnclosedQuote(quote)
+	}
+	if start < len(data) {
+		return len(data), data[start:], nil
+	}
+	return len(data), nil, nil
+}
+
+// ErrUnclosedQuote reports a quote left open at the end of input.
+type ErrUnclosedQuote byte
+
+func (e ErrUnclosedQuote) Error() string { return fmt.Sprintf("unclosed quote %q", rune(e)) }
@@ -0,0 +1,145 @@
+package stream
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// splitAll returns the tokens of input split by split, read one byte at a
+// time to exercise the handling of partial input.
+func splitAll(input string, split bufio.SplitFunc) ([]string, error) {
+	in := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(input)))
+	in.Split(split)
+	var tokens []string
+	for in.Scan() {
+		tokens = append(tokens, in.Text())
+	}
+	return tokens, in.Err()
+}
+
+func TestSplitByRegexp(t *testing.T) {
+	for _, i := range []struct {
+		Re     string
+		Input  string
+		Tokens string
+		Err    string
+	}{
+		{`\s*([a-z]+|[0-9]+|[()])?`, "(add 12 x)  ", "(|add|12|x|)", ""},
+		{`\s*([a-z]+|[0-9]+|[()])?`, "(add 1.2)", "(|add|1", "input does not match /\\s*([a-z]+|[0-9]+|[()])?/"},
+		{`[a-z]+|\s+|<=?`, "a <= bc", "a| |<=| |bc", ""},
+		{`[a-z]+|\s+|<=?`, "a <", "a| |<", ""},
+		{`é+|\s`, "éé é", "éé| |é", ""},
+	} {
+		tokens, err := splitAll(i.Input, SplitByRegexp(regexp.MustCompile(i.Re)))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+}
+
+func TestSplitOnDelimiterRegexp(t *testing.T) {
+	for _, i := range []struct {
+		Re     string
+		Input  string
+		Tokens string
+		Err    string
+	}{
+		{`\s*[,;]\s*`, "a , b;c ;", "a|b|c", ""},
+		{`\s*[,;]\s*`, "a,,b", "a||b", ""},
+		{`\r?\n`, "a\r\nb\nc", "a|b|c", ""},
+		{`x*`, "ab", "", "delimiter matches the empty string"},
+	} {
+		tokens, err := splitAll(i.Input, SplitOnDelimiterRegexp(regexp.MustCompile(i.Re)))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+}
+
+func TestScanQuotedWords(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Tokens string
+		Err    string
+	}{
+		{` a  "b c" d'e f'g  `, `a|"b c"|d'e f'g`, ""},
+		{`"say \"hi\"" 'it''s' x\ y`, `"say \"hi\""|'it''s'|x\ y`, ""},
+		{" tab\there\n\"\"", `tab|here|""`, ""},
+		{`a "b c`, "a", `unclosed quote '"'`},
+		{`a \`, `a|\`, ""},
+		{"", "", ""},
+	} {
+		tokens, err := splitAll(i.Input, ScanQuotedWords)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+}
+
+func TestSplitOn(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Delim  string
+		Keep   bool
+		Tokens string
+	}{
+		{"a\r\n\r\nb\r\nc\r\n\r\n", "\r\n\r\n", false, "a|b\r\nc"},
+		{"a\r\n\r\nb\r\nc\r\n\r\n", "\r\n\r\n", true, "a\r\n\r\n|b\r\nc\r\n\r\n"},
+		{"x\x00\x01\x00\x01y\x00", "\x00\x01", false, "x||y\x00"},
+		{"", "::", false, ""},
+	} {
+		tokens, err := splitAll(i.Input, SplitOn([]byte(i.Delim), i.Keep))
+		if err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+}
+
+func TestSplitCRLF(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Tokens string
+		Err    error
+	}{
+		{"HELO x\r\n\r\nQUIT\r\n", "HELO x||QUIT", nil},
+		{"a\r\nb\nc\r\n", "a", ErrBareLineEnd('\n')},
+		{"a\r\nb\rc\r\n", "a", ErrBareLineEnd('\r')},
+		{"a\r\nb\r", "a", ErrBareLineEnd('\r')},
+		{"a\r\nb", "a", io.ErrUnexpectedEOF},
+		{"abcdefgh\r\nabcdefghi\r\n", "abcdefgh", ErrTokenSize(8)},
+		{"", "", nil},
+	} {
+		tokens, err := splitAll(i.Input, SplitCRLF(8))
+		if err != i.Err {
+			t.Errorf("input %q: expect error %v; got %v", i.Input, i.Err, err)
+		}
+		if got := strings.Join(tokens, "|"); got != i.Tokens {
+			t.Errorf("input %q: expect tokens %q; got %q", i.Input, i.Tokens, got)
+		}
+	}
+	if err := ErrBareLineEnd('\n').Error(); err != `bare '\n' without CRLF` {
+		t.Errorf("unexpected message %q", err)
+	}
+}
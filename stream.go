@@ -52,6 +52,19 @@ func Run(e Enumerator, it Iteratee) (err error) {
 	}
 }
 
+// step feeds token to it until the token is consumed, it reaches a
+// final state or an error occurs. Transitions that do not consume the
+// token are followed on the same token.
+func step(it Iteratee, token []byte) (Iteratee, bool, error) {
+	for {
+		next, read, err := it.Next(token)
+		if err != nil || read || next == nil {
+			return next, read, err
+		}
+		it = next
+	}
+}
+
 // Simple utility Iteratees.
 
 // eofI ensures there is no trailing input.
@@ -75,6 +88,34 @@ var (
 	Skip = skipI{} // an Iteratee that skips exactly one token.
 )
 
+// Succeed finishes immediately without consuming anything.
+func Succeed() Iteratee {
+	return succeedI{}
+}
+
+// succeedI implements Succeed().
+type succeedI struct{}
+
+func (_ succeedI) Final() error { return nil }
+func (_ succeedI) Next(token []byte) (Iteratee, bool, error) {
+	return nil, false, nil
+}
+
+// Fail fails with err on anything, including the end of input.
+func Fail(err error) Iteratee {
+	return failI{err}
+}
+
+// failI implements Fail().
+type failI struct {
+	Err error
+}
+
+func (it failI) Final() error { return it.Err }
+func (it failI) Next(token []byte) (Iteratee, bool, error) {
+	return nil, false, it.Err
+}
+
 // Match requires the next token to be exactly the underlying string
 // (not EOF, not anything else). When the next token matches, it
 // finishes successfully. Otherwise an error is returned.
@@ -181,6 +222,9 @@ type starI struct {
 func (it starI) Final() error { return nil }
 func (it starI) Next(token []byte) (Iteratee, bool, error) {
 	next, read, err := it.A.Next(token)
+	if isCut(err) {
+		return nil, false, err
+	}
 	if err != nil {
 		return nil, false, nil
 	}
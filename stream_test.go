@@ -16,41 +16,9 @@ func (i *CopyIteratee) Next(token []byte) (Iteratee, bool, error) {
 	return i, true, nil
 }
 
-func isSpace(b byte) bool {
-	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == '\v' || b == '\xA0' || b == '\x85'
-}
-
-func LispTokenizer(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	// skip white spaces
-	var (
-		i int
-		b byte
-	)
-	for i, b = range data {
-		if !isSpace(b) {
-			break
-		}
-	}
-	if i == len(data) {
-		return i, nil, nil
-	}
-	if isSpace(b) {
-		return len(data), nil, nil
-	}
-	if b == '(' || b == ')' {
-		return i + 1, data[i : i+1], nil
-	}
-	for j, b := range data[i+1:] {
-		if isSpace(b) {
-			return j + i + 2, data[i : j+i+1], nil
-		} else if b == '(' || b == ')' {
-			return j + i + 1, data[i : j+i+1], nil
-		}
-	}
-	if atEOF {
-		return len(data), data[i:], nil
-	}
-	return i, nil, nil
+// runWords runs it on the space separated tokens of s.
+func runWords(s string, it Iteratee) error {
+	return Run(NewScanEnumeratorWith(strings.NewReader(s), bufio.ScanWords), it)
 }
 
 var (
@@ -100,7 +68,7 @@ func TestFoo(t *testing.T) {
 		{"ab(\tcd\n e ) ", []string{"ab", "(", "cd", "e", ")"}},
 	} {
 		var tok CopyIteratee
-		enum := NewScanEnumeratorWith(strings.NewReader(i.Input), LispTokenizer)
+		enum := NewScanEnumeratorWith(strings.NewReader(i.Input), SplitSExpr)
 		if err := Run(enum, &tok); err != nil {
 			t.Errorf("unexpected error: input %q; error %q", i, err)
 		} else if !reflect.DeepEqual([]string(tok), i.Tokens) {
@@ -134,3 +102,27 @@ func TestFoo(t *testing.T) {
 		t.Errorf("expect error")
 	}
 }
+
+func TestSucceedFail(t *testing.T) {
+	errDeprecated := errors.New("deprecated")
+	option := Alt(Seq(Match("-v"), Succeed()), Seq(Match("-x"), Fail(errDeprecated)), Succeed())
+	for _, i := range []struct {
+		Input string
+		Err   error
+	}{
+		{"-v", nil},
+		{"", nil},
+		{"-x", errDeprecated},
+	} {
+		err := runWords(i.Input, Seq(option, EOF))
+		if te, ok := err.(TokenErr); ok {
+			err = te.Err
+		}
+		if err != i.Err {
+			t.Errorf("input %q: expect error %v; got %v", i.Input, i.Err, err)
+		}
+	}
+	if err := Fail(errDeprecated).Final(); err != errDeprecated {
+		t.Errorf("unexpected error %v", err)
+	}
+}
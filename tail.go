@@ -0,0 +1,147 @@
+package stream
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// TailEnumerator is a ScanEnumerator that follows a file as it grows,
+// like "tail -f". Reaching the end of the file only waits for more
+// data; the Iteratee is given the end of input once Stop has been called
+// and all the data written before has been read. A truncated file is
+// read again from its start, and a file replaced under the same name,
+// e.g. by log rotation, is reopened.
+type TailEnumerator struct {
+	*ScanEnumerator
+	r *tailReader
+}
+
+// NewTailEnumerator creates a TailEnumerator reading the file called
+// name from its start and splitting it with split. The file is checked
+// for new data every poll, or every 250ms if poll <= 0.
+func NewTailEnumerator(name string, split bufio.SplitFunc, poll time.Duration, opts ...ScanOption) (*TailEnumerator, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if poll <= 0 {
+		poll = defaultPollInterval
+	}
+	r := &tailReader{name: name, f: f, poll: poll, stop: make(chan struct{})}
+	return &TailEnumerator{newScanEnumerator(r, split, newScanConfig(opts)), r}, nil
+}
+
+// Stop makes the TailEnumerator end its input once it has read the data
+// already written. It may be called from any goroutine, and more than
+// once. The file is closed when the end of input is reached.
+func (e *TailEnumerator) Stop() {
+	e.r.once.Do(func() { close(e.r.stop) })
+}
+
+// Close stops the TailEnumerator and closes the file, e.g. after a run
+// that ended with an error. Unlike Stop, it must not be called while a
+// step is in progress. It may be called more than once.
+func (e *TailEnumerator) Close() error {
+	e.Stop()
+	return e.r.close()
+}
+
+// defaultPollInterval is how often a TailEnumerator checks the file for
+// new data by default.
+const defaultPollInterval = 250 * time.Millisecond
+
+// tailReader reads from the file called name, waiting for more data at
+// the end of the file until stop is closed. offset is the position in f,
+// and stopped is set once stop is seen. timer is reused across polls.
+type tailReader struct {
+	name    string
+	f       *os.File
+	offset  int64
+	poll    time.Duration
+	timer   *time.Timer
+	stop    chan struct{}
+	once    sync.Once
+	stopped bool
+}
+
+// close closes f, if still open, and stops timer.
+func (r *tailReader) close() error {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+func (r *tailReader) Read(p []byte) (int, error) {
+	for {
+		if r.f == nil {
+			return 0, io.EOF
+		}
+		n, err := r.f.Read(p)
+		r.offset += int64(n)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+		if r.stopped {
+			r.close()
+			continue
+		}
+		if moved, err := r.follow(); err != nil || moved {
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if r.timer == nil {
+			r.timer = time.NewTimer(r.poll)
+		} else {
+			r.timer.Reset(r.poll)
+		}
+		select {
+		case <-r.stop:
+			// Read what was written before Stop.
+			r.stopped = true
+			r.timer.Stop()
+		case <-r.timer.C:
+		}
+	}
+}
+
+// follow checks at the end of f whether the file has been truncated or
+// replaced, and if so moves to the start of the new content. A missing
+// file is assumed to be in the middle of a rotation.
+func (r *tailReader) follow() (bool, error) {
+	fi, err := os.Stat(r.name)
+	if err != nil {
+		return false, nil
+	}
+	cur, err := r.f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if !os.SameFile(fi, cur) {
+		f, err := os.Open(r.name)
+		if err != nil {
+			return false, nil
+		}
+		r.f.Close()
+		r.f, r.offset = f, 0
+		return true, nil
+	}
+	if fi.Size() < r.offset {
+		if _, err := r.f.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		r.offset = 0
+		return true, nil
+	}
+	return false, nil
+}
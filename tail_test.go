@@ -0,0 +1,80 @@
+package stream
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTailEnumerator(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "log")
+	if err := os.WriteFile(name, []byte("a\nb\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e, err := NewTailEnumerator(name, bufio.ScanLines, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	done := make(chan error)
+	go func() {
+		done <- Run(e, OnToken(Star(Skip), func(token []byte) { lines = append(lines, string(token)) }))
+	}()
+
+	// write waits for the data so far to be read, then writes data with how.
+	write := func(how func(string, []byte, os.FileMode) error, data string) {
+		time.Sleep(20 * time.Millisecond)
+		if err := how(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendFile := func(name string, data []byte, perm os.FileMode) error {
+		f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, perm)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write(data)
+		return err
+	}
+	rotate := func(name string, data []byte, perm os.FileMode) error {
+		if err := os.Rename(name, name+".1"); err != nil {
+			return err
+		}
+		return os.WriteFile(name, data, perm)
+	}
+
+	write(appendFile, "c\n")       // grows
+	write(os.WriteFile, "d\n")     // truncated
+	write(rotate, "e\n")           // replaced
+	write(appendFile, "f\ng\nh\n") // grows again, read after Stop
+	e.Stop()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if got := strings.Join(lines, " "); got != "a b c d e f g h" {
+		t.Errorf("expect %q; got %q", "a b c d e f g h", got)
+	}
+
+	// Close releases the file after a failed run.
+	e, err = NewTailEnumerator(name, bufio.ScanLines, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Run(e, Match("x")); err == nil {
+		t.Error("expect error")
+	}
+	if err := e.Close(); err != nil || e.r.f != nil {
+		t.Errorf("expect the file closed; got %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Errorf("unexpected error %v on a second Close", err)
+	}
+
+	if _, err := NewTailEnumerator(filepath.Join(t.TempDir(), "missing"), bufio.ScanLines, 0); !os.IsNotExist(err) {
+		t.Errorf("expect a missing file; got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package stream
+
+import "io"
+
+// Tee returns an Enumerator that writes each token consumed from e to
+// w, followed by sep. An error writing to w fails the step.
+func Tee(e Enumerator, w io.Writer, sep []byte) Enumerator {
+	return teeEnumerator{e, w, sep}
+}
+
+// teeEnumerator implements Tee().
+type teeEnumerator struct {
+	e   Enumerator
+	w   io.Writer
+	sep []byte
+}
+
+func (e teeEnumerator) Step(it Iteratee) (Iteratee, error) {
+	return e.e.Step(teeI{it, e})
+}
+
+// teeI writes the tokens consumed by A.
+type teeI struct {
+	A Iteratee
+	E teeEnumerator
+}
+
+func (it teeI) Final() error { return it.A.Final() }
+func (it teeI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil || !read {
+		return next, read, err
+	}
+	if _, err := it.E.w.Write(token); err != nil {
+		return nil, false, err
+	}
+	if _, err := it.E.w.Write(it.E.sep); err != nil {
+		return nil, false, err
+	}
+	return next, read, nil
+}
@@ -0,0 +1,31 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTee(t *testing.T) {
+	for _, i := range []struct {
+		Input, Output string
+		OK            bool
+	}{
+		{"( x   x )", "(|x|x|)|", true},
+		{"( x y )", "(|x|", false},
+		{"", "", false},
+	} {
+		var out bytes.Buffer
+		e := Tee(NewScanEnumeratorWith(strings.NewReader(i.Input), bufio.ScanWords), &out, []byte("|"))
+		err := Run(e, Seq(Match("("), Star(Match("x")), Match(")"), EOF))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+		if out.String() != i.Output {
+			t.Errorf("input %q: expect output %q; got %q", i.Input, i.Output, out.String())
+		}
+	}
+}
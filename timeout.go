@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Timeout returns an Enumerator that fails with ErrTimeout when a step
+// of e takes longer than d, e.g. when a client sends nothing. If final
+// is true, the Iteratee is then given the end of input to finish any
+// left-over work; its error is discarded in favour of ErrTimeout.
+//
+// The stalled step is abandoned but keeps running in the background:
+// the Iteratee is guarded so that it is not run again afterwards, but e
+// must not be used any more. Each step runs in a new goroutine.
+func Timeout(e Enumerator, d time.Duration, final bool) Enumerator {
+	return &timeoutEnumerator{e: e, d: d, final: final, g: &guard{}}
+}
+
+// timeoutEnumerator implements Timeout(). err is set once a step has
+// timed out.
+type timeoutEnumerator struct {
+	e     Enumerator
+	d     time.Duration
+	final bool
+	g     *guard
+	err   error
+}
+
+func (e *timeoutEnumerator) Step(it Iteratee) (Iteratee, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	type result struct {
+		next Iteratee
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		next, err := e.e.Step(guardI{it, e.g})
+		done <- result{next, err}
+	}()
+	timer := time.NewTimer(e.d)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.next, r.err
+	case <-timer.C:
+	}
+	e.err = ErrTimeout(e.d)
+	e.g.mu.Lock()
+	defer e.g.mu.Unlock()
+	e.g.dead = true
+	if e.final {
+		it.Final()
+	}
+	return nil, e.err
+}
+
+// guard keeps an Iteratee from running after a timeout.
+type guard struct {
+	mu   sync.Mutex
+	dead bool
+}
+
+// guardI runs A unless G is dead.
+type guardI struct {
+	A Iteratee
+	G *guard
+}
+
+func (it guardI) Final() error {
+	it.G.mu.Lock()
+	defer it.G.mu.Unlock()
+	if it.G.dead {
+		return errAbandoned
+	}
+	return it.A.Final()
+}
+
+func (it guardI) Next(token []byte) (Iteratee, bool, error) {
+	it.G.mu.Lock()
+	defer it.G.mu.Unlock()
+	if it.G.dead {
+		return nil, false, errAbandoned
+	}
+	return it.A.Next(token)
+}
+
+// errAbandoned is returned to an abandoned step; nobody sees it.
+var errAbandoned = errors.New("abandoned after timeout")
+
+// ErrTimeout reports a step that took longer than the limit.
+type ErrTimeout time.Duration
+
+func (e ErrTimeout) Error() string { return fmt.Sprintf("no token within %v", time.Duration(e)) }
@@ -0,0 +1,36 @@
+package stream
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	for _, final := range []bool{false, true} {
+		ch := make(chan []byte, 2)
+		ch <- []byte("x")
+		ch <- []byte("x")
+		n, finalCalled := 0, false
+		count := OnFinal(OnToken(Star(Match("x")), func([]byte) { n++ }), func(error) { finalCalled = true })
+		err := Run(Timeout(NewChanEnumerator(ch), 10*time.Millisecond, final), count)
+		if err != ErrTimeout(10*time.Millisecond) || err.Error() != "no token within 10ms" {
+			t.Errorf("final %v: unexpected error %v", final, err)
+		}
+		if n != 2 || finalCalled != final {
+			t.Errorf("final %v: expect 2 tokens; got %d tokens and final %v", final, n, finalCalled)
+		}
+		// A late token is not seen.
+		ch <- []byte("x")
+		time.Sleep(time.Millisecond)
+		if n != 2 {
+			t.Errorf("final %v: unexpected token after timeout", final)
+		}
+	}
+
+	ch := make(chan []byte, 1)
+	ch <- []byte("x")
+	close(ch)
+	if err := Run(Timeout(NewChanEnumerator(ch), time.Second, false), Seq(Match("x"), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
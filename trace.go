@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"fmt"
+	"io"
+)
+
+// Trace runs it and writes a line to w for each of its transitions,
+// prefixed by name, e.g.
+//
+//	expr: "x" consumed -> stream.thenI
+//	expr: ";" not consumed -> done
+//	expr: "]" error: expect ")"
+//	expr: <eof> error: expect ")"
+//
+// The next state is shown by its type. Write errors are ignored.
+func Trace(name string, it Iteratee, w io.Writer) Iteratee {
+	return traceI{name, it, w}
+}
+
+// traceI implements Trace().
+type traceI struct {
+	Name string
+	A    Iteratee
+	W    io.Writer
+}
+
+func (it traceI) Final() error {
+	err := it.A.Final()
+	if err != nil {
+		fmt.Fprintf(it.W, "%s: <eof> error: %v\n", it.Name, err)
+	} else {
+		fmt.Fprintf(it.W, "%s: <eof> -> done\n", it.Name)
+	}
+	return err
+}
+
+func (it traceI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		fmt.Fprintf(it.W, "%s: %q error: %v\n", it.Name, token, err)
+		return nil, false, err
+	}
+	consumed := "consumed"
+	if !read {
+		consumed = "not consumed"
+	}
+	if next == nil {
+		fmt.Fprintf(it.W, "%s: %q %s -> done\n", it.Name, token, consumed)
+		return nil, read, nil
+	}
+	fmt.Fprintf(it.W, "%s: %q %s -> %T\n", it.Name, token, consumed, next)
+	it.A = next
+	return it, read, nil
+}
+
+// OnToken runs it and calls fn with each token it consumes. As in Next,
+// the token is only valid until fn returns.
+func OnToken(it Iteratee, fn func(token []byte)) Iteratee {
+	return onTokenI{it, fn}
+}
+
+// onTokenI implements OnToken().
+type onTokenI struct {
+	A  Iteratee
+	Fn func([]byte)
+}
+
+func (it onTokenI) Final() error { return it.A.Final() }
+func (it onTokenI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		return nil, false, err
+	}
+	if read {
+		it.Fn(token)
+	}
+	if next == nil {
+		return nil, read, nil
+	}
+	it.A = next
+	return it, read, nil
+}
+
+// OnFinal runs it and calls fn once it finishes, with nil, or fails,
+// with the error, which is then returned as is.
+func OnFinal(it Iteratee, fn func(err error)) Iteratee {
+	return onFinalI{it, fn}
+}
+
+// onFinalI implements OnFinal().
+type onFinalI struct {
+	A  Iteratee
+	Fn func(error)
+}
+
+func (it onFinalI) Final() error {
+	err := it.A.Final()
+	it.Fn(err)
+	return err
+}
+
+func (it onFinalI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		it.Fn(err)
+		return nil, false, err
+	}
+	if next == nil {
+		it.Fn(nil)
+		return nil, read, nil
+	}
+	it.A = next
+	return it, read, nil
+}
@@ -0,0 +1,58 @@
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	for _, i := range []struct {
+		Input string
+		Log   string
+	}{
+		{"( x x ) y", `list: "(" consumed -> stream.seqI
+list: "x" consumed -> stream.thenI
+list: "x" consumed -> stream.thenI
+list: ")" not consumed -> stream.seqI
+list: ")" consumed -> stream.seqI
+list: "y" not consumed -> done
+`},
+		{"( x", `list: "(" consumed -> stream.seqI
+list: "x" consumed -> stream.thenI
+list: <eof> error: expect ")"
+`},
+		{"( ]", `list: "(" consumed -> stream.seqI
+list: "]" not consumed -> stream.seqI
+list: "]" error: expect ")"
+`},
+	} {
+		var log bytes.Buffer
+		runWords(i.Input, Trace("list", Seq(Match("("), Star(Match("x")), Match(")")), &log))
+		if log.String() != i.Log {
+			t.Errorf("input %q: expect log\n%s\ngot\n%s", i.Input, i.Log, log.String())
+		}
+	}
+}
+
+func TestOnTokenOnFinal(t *testing.T) {
+	for _, i := range []struct {
+		Input  string
+		Tokens string
+		Final  string
+	}{
+		{"( x x ) y", "(xx)", "<nil>"},
+		{"( x", "(x", `expect ")"`},
+		{"( ]", "(", `expect ")"`},
+	} {
+		var tokens string
+		final := "not called"
+		list := Seq(Match("("), Star(Match("x")), Match(")"))
+		runWords(i.Input, OnFinal(OnToken(list, func(token []byte) { tokens += string(token) }), func(err error) {
+			final = fmt.Sprint(err)
+		}))
+		if tokens != i.Tokens || final != i.Final {
+			t.Errorf("input %q: expect tokens %q and final %q; got %q and %q", i.Input, i.Tokens, i.Final, tokens, final)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package stream
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Filter consumes the tokens on which pred returns false and forwards
+// the others to it. It finishes when it does.
+func Filter(pred func(token []byte) bool, it Iteratee) Iteratee {
+	return filterI{pred, it}
+}
+
+// filterI implements Filter().
+type filterI struct {
+	Pred func([]byte) bool
+	A    Iteratee
+}
+
+func (it filterI) Final() error { return it.A.Final() }
+func (it filterI) Next(token []byte) (Iteratee, bool, error) {
+	if !it.Pred(token) {
+		return it, true, nil
+	}
+	next, read, err := it.A.Next(token)
+	if err != nil || next == nil {
+		return nil, read, err
+	}
+	return filterI{it.Pred, next}, read, nil
+}
+
+// MapTokens forwards f(token) instead of each token to it. f must not
+// modify its argument; it may return the argument, a slice of it, or a
+// new slice. Like the input token, the result only needs to remain
+// valid until it.Next() returns, so f may reuse a buffer. When it does
+// not consume a token, f is applied again when the token is presented
+// again.
+func MapTokens(f func(token []byte) []byte, it Iteratee) Iteratee {
+	return mapI{f, it}
+}
+
+// mapI implements MapTokens().
+type mapI struct {
+	F func([]byte) []byte
+	A Iteratee
+}
+
+func (it mapI) Final() error { return it.A.Final() }
+func (it mapI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(it.F(token))
+	if err != nil || next == nil {
+		return nil, read, err
+	}
+	return mapI{it.F, next}, read, nil
+}
+
+// WithSkipper discards the input matched by skip (e.g. whitespace or
+// comments) before each token forwarded to it. A token starts a
+// skipped run when skip consumes it; skip then runs to its final state,
+// failing WithSkipper if it fails (e.g. on an unterminated comment).
+func WithSkipper(skip, it Iteratee) Iteratee {
+	return skipperI{skip, it, nil}
+}
+
+// skipperI implements WithSkipper(). Skipping is the state of the
+// current skipped run, if any.
+type skipperI struct {
+	Skip, A, Skipping Iteratee
+}
+
+func (it skipperI) Final() error {
+	if it.Skipping != nil {
+		if err := it.Skipping.Final(); err != nil {
+			return err
+		}
+	}
+	return it.A.Final()
+}
+
+func (it skipperI) Next(token []byte) (Iteratee, bool, error) {
+	if it.Skipping != nil {
+		next, read, err := it.Skipping.Next(token)
+		if err != nil {
+			return nil, false, err
+		}
+		it.Skipping = next
+		return it, read, nil
+	}
+	if next, read, err := step(it.Skip, token); err == nil && read {
+		it.Skipping = next
+		return it, true, nil
+	}
+	next, read, err := it.A.Next(token)
+	if err != nil || next == nil {
+		return nil, read, err
+	}
+	it.A = next
+	return it, read, nil
+}
+
+// Label wraps errors from it in a LabelErr with name.
+func Label(it Iteratee, name string) Iteratee {
+	return labelI{it, name}
+}
+
+// labelI implements Label().
+type labelI struct {
+	A    Iteratee
+	Name string
+}
+
+func (it labelI) Final() error { return it.wrap(it.A.Final()) }
+func (it labelI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil || next == nil {
+		return nil, read, it.wrap(err)
+	}
+	return labelI{next, it.Name}, read, nil
+}
+
+func (it labelI) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return LabelErr{it.Name, err}
+}
+
+// LabelErr is an error from a labeled sub-grammar (see Label).
+type LabelErr struct {
+	Name string
+	Err  error
+}
+
+func (e LabelErr) Error() string { return "in " + e.Name + ": " + e.Err.Error() }
+func (e LabelErr) Unwrap() error { return e.Err }
+
+// LimitTokens fails with ErrTokenLimit when it consumes more than max
+// tokens.
+func LimitTokens(it Iteratee, max int) Iteratee {
+	return limitI{it, 0, max}
+}
+
+// limitI implements LimitTokens(). N is the number of consumed tokens.
+type limitI struct {
+	A      Iteratee
+	N, Max int
+}
+
+func (it limitI) Final() error { return it.A.Final() }
+func (it limitI) Next(token []byte) (Iteratee, bool, error) {
+	next, read, err := it.A.Next(token)
+	if err != nil {
+		return nil, false, err
+	}
+	if read {
+		if it.N == it.Max {
+			return nil, false, ErrTokenLimit(it.Max)
+		}
+		it.N++
+	}
+	if next == nil {
+		return nil, read, nil
+	}
+	it.A = next
+	return it, read, nil
+}
+
+// ErrTokenLimit reports that more tokens than the limit are consumed.
+type ErrTokenLimit int
+
+func (e ErrTokenLimit) Error() string { return fmt.Sprintf("more than %s", plural(int(e), "token")) }
+
+// LimitTokenSize fails with ErrTokenSize on any token longer than max
+// bytes, before it sees the token.
+func LimitTokenSize(it Iteratee, max int) Iteratee {
+	return sizeI{it, max}
+}
+
+// sizeI implements LimitTokenSize().
+type sizeI struct {
+	A   Iteratee
+	Max int
+}
+
+func (it sizeI) Final() error { return it.A.Final() }
+func (it sizeI) Next(token []byte) (Iteratee, bool, error) {
+	if len(token) > it.Max {
+		return nil, false, ErrTokenSize(it.Max)
+	}
+	next, read, err := it.A.Next(token)
+	if err != nil || next == nil {
+		return nil, read, err
+	}
+	it.A = next
+	return it, read, nil
+}
+
+// ErrTokenSize reports a token longer than the limit.
+type ErrTokenSize int
+
+func (e ErrTokenSize) Error() string {
+	return fmt.Sprintf("token longer than %s", plural(int(e), "byte"))
+}
+
+// Lazy defers calling f until its result is first needed, which allows
+// an Iteratee to refer to itself or to one defined later, e.g.
+//
+//	var list Iteratee
+//	list = Seq(Match("("), Star(Or(Match("x"), Lazy(func() Iteratee { return list }))), Match(")"))
+//
+// f is called at most once.
+func Lazy(f func() Iteratee) Iteratee {
+	return &lazyI{f: f}
+}
+
+// lazyI implements Lazy().
+type lazyI struct {
+	once sync.Once
+	f    func() Iteratee
+	it   Iteratee
+}
+
+func (l *lazyI) get() Iteratee {
+	l.once.Do(func() { l.it = l.f() })
+	return l.it
+}
+
+func (l *lazyI) Final() error                              { return l.get().Final() }
+func (l *lazyI) Next(token []byte) (Iteratee, bool, error) { return l.get().Next(token) }
+
+// Rec returns the fixed point of f: an Iteratee that behaves like the
+// result of f, to which self refers. f must not run self, e.g.
+//
+//	list := Rec(func(list Iteratee) Iteratee {
+//		return Seq(Match("("), Star(Or(Match("x"), list)), Match(")"))
+//	})
+func Rec(f func(self Iteratee) Iteratee) Iteratee {
+	r := &recI{}
+	r.it = f(r)
+	return r
+}
+
+// recI implements Rec().
+type recI struct {
+	it Iteratee
+}
+
+func (r *recI) Final() error                              { return r.it.Final() }
+func (r *recI) Next(token []byte) (Iteratee, bool, error) { return r.it.Next(token) }
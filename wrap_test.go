@@ -0,0 +1,156 @@
+package stream
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	noComment := func(token []byte) bool { return !bytes.HasPrefix(token, []byte("#")) }
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"a b", true},
+		{"#x a #y #z b #w", true},
+		{"a #x c", false},
+	} {
+		err := runWords(i.Input, Seq(Filter(noComment, Seq(Match("a"), Match("b"))), EOF))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+
+	// Filter stops as soon as the inner Iteratee finishes.
+	if err := runWords("a #x", Seq(Filter(noComment, Match("a")), Match("#x"), EOF)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestMapTokens(t *testing.T) {
+	lower := func(token []byte) []byte { return bytes.ToLower(token) }
+	trim := func(token []byte) []byte { return bytes.Trim(token, ",") }
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"select a, b", true},
+		{"SELECT A, B", true},
+		{"SELECT A B", true},
+		{"SELECT C, B", false},
+	} {
+		err := runWords(i.Input, MapTokens(lower, MapTokens(trim, Seq(Match("select"), Match("a"), Match("b"), EOF))))
+		if i.OK && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if !i.OK && err == nil {
+			t.Errorf("input %q: expect error", i.Input)
+		}
+	}
+}
+
+func TestLabel(t *testing.T) {
+	value := Label(Or(Match("true"), Match("false")), "value")
+	field := Label(Seq(Skip, Match("="), value), "field")
+	for _, i := range []struct {
+		Input, Err string
+	}{
+		{"a = true b = false", ""},
+		{"a = true b : false", `token ":" at offset 11 (token #4): in field: expect "="`},
+		{"a = true b = 1", `token "1" at offset 13 (token #5): in field: in value: no alternative matched: expect "true"; expect "false"`},
+		{"a = true b =", `in field: in value: no alternative matched: expect "true"; expect "false"`},
+	} {
+		err := runWords(i.Input, Seq(Star(field), EOF))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
+
+func TestRec(t *testing.T) {
+	// Mutually recursive: a list holds atoms and lists.
+	var list, item Iteratee
+	list = Seq(Match("("), Star(Lazy(func() Iteratee { return item })), Match(")"))
+	item = Or(Match("x"), list)
+	rec := Rec(func(list Iteratee) Iteratee {
+		return Seq(Match("("), Star(Or(Match("x"), list)), Match(")"))
+	})
+	for _, i := range []struct {
+		Input string
+		OK    bool
+	}{
+		{"( )", true},
+		{"( x ( x ( ) ) x )", true},
+		{"( x ( x )", false},
+		{"( y )", false},
+	} {
+		for _, it := range []Iteratee{list, rec} {
+			err := runWords(i.Input, Seq(it, EOF))
+			if i.OK && err != nil {
+				t.Errorf("input %q: unexpected error %v", i.Input, err)
+			} else if !i.OK && err == nil {
+				t.Errorf("input %q: expect error", i.Input)
+			}
+		}
+	}
+}
+
+func TestLimitTokens(t *testing.T) {
+	field := Seq(LimitTokens(Star(Seq(Not(Match(";")), Skip)), 3), Match(";"))
+	for _, i := range []struct {
+		Input, Err string
+	}{
+		{"a ;", ""},
+		{"a b c ; ;", ""},
+		{"a b c d ;", `token "d" at offset 6 (token #3): more than 3 tokens`},
+	} {
+		err := runWords(i.Input, Seq(Star(field), EOF))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
+
+func TestLimitTokenSize(t *testing.T) {
+	for _, i := range []struct {
+		Input, Err string
+	}{
+		{"ab abc a", ""},
+		{"", ""},
+		{"ab abcd a", `token "abcd" at offset 3 (token #1): token longer than 3 bytes`},
+	} {
+		err := runWords(i.Input, LimitTokenSize(Seq(Star(Skip), EOF), 3))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
+
+func TestWithSkipper(t *testing.T) {
+	comment := Seq(Match("/*"), Star(Seq(Not(Match("*/")), Skip)), Match("*/"))
+	skip := Or(Plus(Match("_")), comment)
+	assign := Seq(Skip, Match("="), Skip, Match(";"))
+	for _, i := range []struct {
+		Input, Err string
+	}{
+		{"a = 1 ;", ""},
+		{"_ a _ _ = /* x = y */ 1 _ ; _", ""},
+		{"/* a */ /* b */ a = 1 ;", ""},
+		{"a = 1 /* ;", `expect "*/"`},
+		{"a _ 1 ;", `token "1" at offset 4 (token #2): expect "="`},
+	} {
+		err := runWords(i.Input, WithSkipper(skip, Seq(Star(assign), EOF)))
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package stream
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+)
+
+// NewXMLEnumerator creates an Enumerator over the XML tokens read from
+// r (see xml.Decoder.Token). The first byte of each token tells its
+// kind:
+//
+//   - "<name" starts an element, followed by a token "@name=value" for
+//     each of its attributes;
+//   - "/name" ends an element;
+//   - "#text" is character data, which is skipped when it is only
+//     white space;
+//   - "!text" is a comment;
+//   - "?target inst" is a processing instruction;
+//   - "%text" is a directive, e.g. "%DOCTYPE html".
+//
+// A name in a namespace is written as "{space}local", where space is
+// the namespace URL.
+func NewXMLEnumerator(r io.Reader) Enumerator {
+	return &xmlEnumerator{dec: xml.NewDecoder(r)}
+}
+
+// xmlEnumerator implements NewXMLEnumerator(). pending holds the
+// tokens decoded but not consumed yet and index is the position of the
+// current token.
+type xmlEnumerator struct {
+	dec     *xml.Decoder
+	pending [][]byte
+	index   int
+}
+
+func (e *xmlEnumerator) Step(it Iteratee) (Iteratee, error) {
+	for len(e.pending) == 0 {
+		t, err := e.dec.Token()
+		if err == io.EOF {
+			return nil, it.Final()
+		}
+		if err != nil {
+			return nil, err
+		}
+		e.pending = encodeXMLToken(t)
+	}
+	token := e.pending[0]
+	next, read, err := it.Next(token)
+	if err != nil {
+		return nil, WrapTokenErrorAt(token, -1, e.index, err)
+	}
+	if read {
+		e.pending = e.pending[1:]
+		e.index++
+	}
+	return next, nil
+}
+
+func encodeXMLToken(t xml.Token) [][]byte {
+	switch t := t.(type) {
+	case xml.StartElement:
+		tokens := [][]byte{[]byte("<" + xmlName(t.Name))}
+		for _, a := range t.Attr {
+			tokens = append(tokens, []byte("@"+xmlName(a.Name)+"="+a.Value))
+		}
+		return tokens
+	case xml.EndElement:
+		return [][]byte{[]byte("/" + xmlName(t.Name))}
+	case xml.CharData:
+		if len(bytes.TrimSpace(t)) == 0 {
+			return nil
+		}
+		return [][]byte{append([]byte("#"), t...)}
+	case xml.Comment:
+		return [][]byte{append([]byte("!"), t...)}
+	case xml.ProcInst:
+		return [][]byte{append([]byte("?"+t.Target+" "), t.Inst...)}
+	case xml.Directive:
+		return [][]byte{append([]byte("%"), t...)}
+	}
+	return nil
+}
+
+func xmlName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return "{" + n.Space + "}" + n.Local
+}
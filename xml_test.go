@@ -0,0 +1,42 @@
+package stream
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestXMLEnumerator(t *testing.T) {
+	const doc = `<?xml version="1.0"?>
+<!DOCTYPE list>
+<list xmlns:x="urn:x">
+  <!-- items -->
+  <item id="1">a &amp; b</item>
+  <x:item/>
+</list>`
+	var tokens []string
+	if err := Run(NewXMLEnumerator(strings.NewReader(doc)), Star(Capture(Skip, appendTo(&tokens)))); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	expected := []string{`?xml version="1.0"`, "%DOCTYPE list", "<list", "@{xmlns}x=urn:x", "! items ", "<item", "@id=1", "#a & b", "/item", "<{urn:x}item", "/{urn:x}item", "/list"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("expect %q; got %q", expected, tokens)
+	}
+
+	item := Seq(Match("<item"), Star(MatchPrefix("@")), MatchPrefix("#"), Match("/item"))
+	list := Seq(Match("<list"), Star(item), Match("/list"), EOF)
+	for _, i := range []struct {
+		Input, Err string
+	}{
+		{`<list><item a="1">x</item><item>y</item></list>`, ""},
+		{`<list><item>x</item><other/></list>`, `token "<other" (token #4): expect "/list"`},
+		{`<list><item>x</list>`, "XML syntax error on line 1: element <item> closed by </list>"},
+	} {
+		err := Run(NewXMLEnumerator(strings.NewReader(i.Input)), list)
+		if i.Err == "" && err != nil {
+			t.Errorf("input %q: unexpected error %v", i.Input, err)
+		} else if i.Err != "" && (err == nil || err.Error() != i.Err) {
+			t.Errorf("input %q: expect error %q; got %v", i.Input, i.Err, err)
+		}
+	}
+}